@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	"github.com/dontbeidle/kaalin/internal/output"
-	"github.com/dontbeidle/kaalin/internal/strutil"
+	"github.com/dontbeidle/kaalin/kaalin"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +27,7 @@ var upperCmd = &cobra.Command{
 			os.Exit(2)
 		}
 
-		result := strutil.Upper(text)
+		result := kaalin.Upper(text)
 
 		if output.JSONOutput {
 			output.PrintJSON(map[string]string{"result": result})
@@ -48,7 +48,7 @@ var lowerCmd = &cobra.Command{
 			os.Exit(2)
 		}
 
-		result := strutil.Lower(text)
+		result := kaalin.Lower(text)
 
 		if output.JSONOutput {
 			output.PrintJSON(map[string]string{"result": result})
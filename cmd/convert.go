@@ -3,21 +3,53 @@ package cmd
 import (
 	"bufio"
 	"fmt"
-	"io"
 	"os"
+	"runtime"
 	"strings"
 
-	"github.com/dontbeidle/kaalin/internal/converter"
+	"github.com/dontbeidle/kaalin/internal/config"
 	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
 	"github.com/spf13/cobra"
 )
 
 var (
-	toCyr   bool
-	toLat   bool
-	file    string
-	outFile string
-	inPlace bool
+	toCyr    bool
+	toLat    bool
+	file     string
+	outFile  string
+	inPlace  bool
+	dir      string
+	outDir   string
+	includes []string
+	excludes []string
+	jobs     int
+
+	dryRun       bool
+	check        bool
+	backupSuffix string
+
+	dictFile   string
+	noDict     bool
+	dictionary *kaalin.Dictionary
+
+	fromAlphabetName string
+	toAlphabetName   string
+	fromAlphabet     kaalin.Alphabet
+	toAlphabet       kaalin.Alphabet
+
+	losslessFile string
+	restoreFile  string
+
+	perSegment bool
+
+	formatName  string
+	docFormat   kaalin.Format
+	frontMatter []string
+	valuePaths  []string
+	columns     []string
+	colSuffix   string
+	markFuzzy   bool
 )
 
 var convertCmd = &cobra.Command{
@@ -30,65 +62,297 @@ Input sources (by priority):
   1. Argument: kaalin convert "Sálem"
   2. Pipe:     echo "Sálem" | kaalin convert
   3. File:     kaalin convert -f input.txt
-  4. Interactive mode (if none of the above)`,
+  4. Interactive mode (if none of the above)
+
+Directories:
+  kaalin convert --dir src/ --out-dir dst/ --include '*.txt' --exclude 'vendor/**'
+  kaalin convert --dir docs/ --in-place
+
+In-place edits are written atomically and keep the file mode:
+  kaalin convert -f notes.txt --in-place --dry-run    show a diff, write nothing
+  kaalin convert --dir docs/ --in-place --check       exit 1 if any file would change
+  kaalin convert -f notes.txt --in-place --backup .orig
+
+Piped and file input is converted as a stream, so large files are handled
+in constant memory. Without --to-cyr/--to-lat, the direction is detected
+over the whole input; piped input is spooled to a temporary file for it.
+
+Loanwords and proper nouns can be taken from an exception dictionary:
+--dict builtin uses the built-in list, and --dict FILE or "dict:" in the
+config file adds a file on top of it. Without either, only the letter
+tables are used. Dictionary files hold "latin<TAB>cyrillic" lines or a
+YAML mapping.
+
+Archived texts in the 1994/2009 Latin alphabet (a' o' u' g' n' i') are read
+with --from-alphabet latin-old and written with --to-alphabet latin-old.
+
+Letters such as ъ, ь, щ and э have no Latin spelling of their own. With
+--lossless the words that would not convert back are saved to a sidecar
+file, and --restore uses it to recover the exact original:
+  kaalin convert -l -f doc.txt -o doc.lat.txt --lossless doc.kaalin.json
+  kaalin convert -f doc.lat.txt --restore doc.kaalin.json
+
+Mixed-script documents can be converted passage by passage with
+--auto-per-segment: only the passages not yet in the target script are
+converted (see "kaalin detect").
+
+Letters typed as a base letter and a combining accent (a + U+0301) are
+composed before conversion. With --normalize, look-alikes such as ğ, ñ and
+һ are also read as ǵ, ń and ҳ; without it they are copied as they are.
+--show-substitutions lists what was replaced:
+  kaalin convert -c -f pdf-copy.txt --normalize --show-substitutions
+
+Input encodings are detected from a byte order mark, UTF-16 NUL bytes, an
+XML or HTML charset declaration, or failing UTF-8, as Windows-1251 or
+KOI8-R. Output is UTF-8, except that --in-place keeps each file's own:
+  kaalin convert -c -f doc.txt --output-encoding cp1251 -o doc.cp1251.txt
+Characters the output encoding lacks (cp1251 has no Ә Ғ Қ Ң Ө Ү Ҳ) are
+written as '?' and reported with their line and column.
+
+Documents are converted without touching their markup:
+  kaalin convert -c --format html -f page.html -o page.cyr.html
+HTML and XML: text nodes and the title, alt and placeholder attributes are
+converted, lang="kaa-Latn" becomes lang="kaa-Cyrl" (and back), and
+everything else, including script and style, is kept byte for byte.
+Markdown: prose, link text and alt text are converted; code, URLs, link
+targets, HTML comments and front matter are kept. Front-matter fields are
+converted when named:
+  kaalin convert -c --format markdown --front-matter title,description -f README.md
+SRT and WebVTT: only cue text is converted; numbers, identifiers, timings,
+cue settings, NOTE blocks and inline tags are kept, and the result is
+checked to parse into the same cues.
+JSON and YAML: string values are converted, keys never are, and
+placeholders like {name}, %s and {{.Count}} are kept. --path limits the
+values touched:
+  kaalin convert -c --format json --path '$.messages.*' -f kaa.json
+CSV and TSV: the header row is kept and the selected columns are
+converted row by row, optionally into new columns:
+  kaalin convert -c --format csv --columns name,address --append-suffix _cyr -f people.csv
+PO and XLIFF: translations (msgstr, target) are converted; msgid, source,
+comments, flags and placeholders are kept, and the Language header or
+target language is updated. --fuzzy marks converted entries for review:
+  kaalin convert -c --format po -f kaa_Latn.po -o kaa_Cyrl.po
+DOCX and ODT: the text of the body, headers, footers, footnotes and
+comments is converted run by run, keeping styles and formatting. Files
+ending in .docx, .odt or .epub are recognised without --format:
+  kaalin convert -c -f letter.docx -o letter-cyr.docx
+EPUB: every XHTML document in the manifest is converted like HTML, along
+with the table of contents, dc:title and dc:creator; dc:language is
+updated and the mimetype entry stays first and uncompressed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if toCyr && toLat {
 			output.Error("--to-cyr and --to-lat cannot be used together", "choose only one")
 			os.Exit(2)
 		}
 
-		if inPlace && file == "" {
-			output.Error("--in-place requires --file (-f)", "add the --file flag")
+		if inPlace && file == "" && dir == "" {
+			output.Error("--in-place requires --file (-f) or --dir", "add the --file or --dir flag")
+			os.Exit(2)
+		}
+
+		if inPlace && len(args) > 0 {
+			output.Error("--in-place converts the file itself and cannot be combined with text", "remove the text argument")
+			os.Exit(2)
+		}
+
+		if (dryRun || check || backupSuffix != "") && !inPlace {
+			output.Error("--dry-run, --check and --backup require --in-place", "add the --in-place flag")
+			os.Exit(2)
+		}
+
+		if err := parseAlphabets(); err != nil {
+			output.Error(err.Error(), "use latin, latin-old or cyrillic")
+			os.Exit(2)
+		}
+
+		if err := parseEncodings(); err != nil {
+			output.Error(err.Error(), "use auto, utf-8, utf-16, utf-16le, utf-16be, windows-1251 or koi8-r")
+			os.Exit(2)
+		}
+
+		var err error
+		if docFormat, err = kaalin.ParseFormat(formatName); err != nil {
+			output.Error(err.Error(), "use text, html, xml, markdown, srt, vtt, json, yaml, csv, tsv, po, xliff, docx, odt or epub")
+			os.Exit(2)
+		}
+		if f, ok := kaalin.FormatForFile(file); ok && !cmd.Flags().Changed("format") {
+			docFormat = f
+		}
+
+		if docFormat.IsBinary() {
+			switch {
+			case len(args) > 0:
+				output.Error(docFormat.String()+" documents are read from a file", "use --file (-f) instead of a text argument")
+				os.Exit(2)
+			case dryRun:
+				output.Error("--dry-run cannot show a diff of "+docFormat.String()+" documents", "use --check")
+				os.Exit(2)
+			case inputEncoding != nil || outputEncoding != nil:
+				output.Error(docFormat.String()+" documents are always UTF-8", "remove --input-encoding and --output-encoding")
+				os.Exit(2)
+			case output.JSONOutput && outFile == "" && !inPlace && dir == "":
+				output.Error("--json cannot print a "+docFormat.String()+" document", "write it with --output (-o)")
+				os.Exit(2)
+			case outFile == "" && !inPlace && dir == "" && isTerminal(os.Stdout):
+				output.Error("refusing to write a "+docFormat.String()+" document to the terminal", "write it with --output (-o) or redirect stdout")
+				os.Exit(2)
+			}
+		}
+
+		if docFormat != kaalin.FormatText && (losslessFile != "" || restoreFile != "") {
+			output.Error("--lossless and --restore work on plain text only", "remove the --format flag")
+			os.Exit(2)
+		}
+
+		if len(frontMatter) > 0 && docFormat != kaalin.FormatMarkdown {
+			output.Error("--front-matter requires --format markdown", "add --format markdown")
+			os.Exit(2)
+		}
+
+		if len(valuePaths) > 0 {
+			if docFormat != kaalin.FormatJSON && docFormat != kaalin.FormatYAML {
+				output.Error("--path requires --format json or yaml", "add --format json or --format yaml")
+				os.Exit(2)
+			}
+			if err := kaalin.CheckPaths(valuePaths); err != nil {
+				output.Error(err.Error(), "use selectors like $.messages.*, $..title or $.items[0]")
+				os.Exit(2)
+			}
+		}
+
+		if (len(columns) > 0 || colSuffix != "") && docFormat != kaalin.FormatCSV && docFormat != kaalin.FormatTSV {
+			output.Error("--columns and --append-suffix require --format csv or tsv", "add --format csv or --format tsv")
+			os.Exit(2)
+		}
+
+		if showSubstitutions && (docFormat != kaalin.FormatText || dir != "" || perSegment) {
+			output.Error("--show-substitutions works on plain text only", "remove --format, --dir and --auto-per-segment")
+			os.Exit(2)
+		}
+
+		if markFuzzy && docFormat != kaalin.FormatPO && docFormat != kaalin.FormatXLIFF {
+			output.Error("--fuzzy requires --format po or xliff", "add --format po or --format xliff")
+			os.Exit(2)
+		}
+
+		if noDict && dictFile != "" {
+			output.Error("--dict and --no-dict cannot be used together", "choose only one")
+			os.Exit(2)
+		}
+
+		if outDir != "" && dir == "" {
+			output.Error("--out-dir requires --dir", "add the --dir flag")
+			os.Exit(2)
+		}
+
+		if losslessFile != "" && restoreFile != "" {
+			output.Error("--lossless and --restore cannot be used together", "choose only one")
 			os.Exit(2)
 		}
 
+		if (losslessFile != "" || restoreFile != "") && (inPlace || dir != "") {
+			output.Error("--lossless and --restore cannot be combined with --in-place or --dir", "write the result with --output")
+			os.Exit(2)
+		}
+
+		if restoreFile != "" && (toCyr || toLat || fromAlphabet != kaalin.AlphabetUnknown || toAlphabet != kaalin.AlphabetUnknown) {
+			output.Error("--restore takes the direction from the sidecar", "remove the --to-cyr, --to-lat and alphabet flags")
+			os.Exit(2)
+		}
+
+		if dictionary, err = loadDictionary(); err != nil {
+			output.Error(err.Error(), "check the --dict file or the dict setting in "+config.Path())
+			os.Exit(1)
+		}
+
+		if dir != "" {
+			if len(args) > 0 || file != "" || outFile != "" {
+				output.Error("--dir cannot be combined with text, --file or --output", "use --out-dir or --in-place")
+				os.Exit(2)
+			}
+			if outDir == "" && !inPlace {
+				output.Error("--dir requires --out-dir or --in-place", "choose where to write converted files")
+				os.Exit(2)
+			}
+			if outDir != "" && inPlace {
+				output.Error("--out-dir and --in-place cannot be used together", "choose only one")
+				os.Exit(2)
+			}
+			runDirConvert()
+			return nil
+		}
+
+		// Stream piped and file input unless the whole result is needed for
+		// JSON or a sidecar
+		lossless := losslessFile != "" || restoreFile != ""
+		if len(args) == 0 && !lossless && (!output.JSONOutput || inPlace || outFile != "") {
+			handled, err := runStreamConvert()
+			if err != nil {
+				output.Error(err.Error(), encodingHint(err))
+				os.Exit(1)
+			}
+			if handled {
+				return nil
+			}
+		}
+
 		// Get input text
 		text, err := getInput(args)
 		if err != nil {
-			output.Error(err.Error(), "")
+			output.Error(err.Error(), encodingHint(err))
 			os.Exit(1)
 		}
 
-		// Detect direction if not specified
-		if !toCyr && !toLat {
-			script := converter.DetectScript(text)
-			if script == "cyrillic" {
-				toLat = true
-			} else {
-				toCyr = true
-			}
+		// Convert (direction is detected if not specified)
+		var result string
+		switch {
+		case losslessFile != "":
+			result, err = losslessConvert(text)
+		case restoreFile != "":
+			result, err = restoreText(text)
+		default:
+			result, err = kaalin.Convert(text, convertOptions(targetScript()))
+		}
+		if err != nil {
+			output.Error(err.Error(), "")
+			os.Exit(1)
 		}
 
-		// Convert
-		var result string
-		if toCyr {
-			result = converter.Latin2Cyrillic(text)
-		} else {
-			result = converter.Cyrillic2Latin(text)
+		var subs []kaalin.Substitution
+		if showSubstitutions {
+			subs = substitutionsOf(text)
+			if !output.JSONOutput {
+				reportSubstitutions(subs)
+			}
 		}
 
 		// Output
 		if outFile != "" {
-			if err := os.WriteFile(outFile, []byte(result), 0644); err != nil {
-				output.Error(fmt.Sprintf("failed to write file: %s", err), "")
+			data, err := encodeString(result)
+			if err == nil {
+				err = os.WriteFile(outFile, data, 0644)
+			}
+			if err != nil {
+				output.Error(fmt.Sprintf("failed to write file: %s", err), encodingHint(err))
 				os.Exit(1)
 			}
 			output.Success(fmt.Sprintf("Converted: %s", outFile))
 			return nil
 		}
 
-		if inPlace {
-			if err := os.WriteFile(file, []byte(result), 0644); err != nil {
-				output.Error(fmt.Sprintf("failed to update file: %s", err), "")
+		switch {
+		case output.JSONOutput && showSubstitutions:
+			output.PrintJSON(map[string]interface{}{"result": result, "substitutions": subs})
+		case output.JSONOutput:
+			output.PrintJSON(map[string]string{"result": result})
+		case outputEncoding != nil && !outputEncoding.IsUTF8():
+			data, err := encodeString(result + "\n")
+			os.Stdout.Write(data)
+			if err != nil {
+				output.Error(err.Error(), encodingHint(err))
 				os.Exit(1)
 			}
-			output.Success(fmt.Sprintf("File updated: %s", file))
-			return nil
-		}
-
-		if output.JSONOutput {
-			output.PrintJSON(map[string]string{"result": result})
-		} else {
+		default:
 			output.ResultLn(result)
 		}
 
@@ -105,26 +369,117 @@ func getInput(args []string) (string, error) {
 	// Priority 2: stdin/pipe
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		data, err := io.ReadAll(os.Stdin)
+		data, err := readInput(os.Stdin)
 		if err != nil {
-			return "", fmt.Errorf("failed to read stdin: %s", err)
+			return "", fmt.Errorf("failed to read stdin: %w", err)
 		}
-		return strings.TrimRight(string(data), "\n"), nil
+		return strings.TrimRight(data, "\n"), nil
 	}
 
 	// Priority 3: file
 	if file != "" {
-		data, err := os.ReadFile(file)
+		f, err := os.Open(file)
 		if err != nil {
 			return "", fmt.Errorf("failed to read file: %s", err)
 		}
-		return string(data), nil
+		defer f.Close()
+		data, err := readInput(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return data, nil
 	}
 
 	// Priority 4: interactive mode
 	return interactiveInput()
 }
 
+// convertOptions returns the conversion options for a target script.
+func convertOptions(to kaalin.Script) kaalin.ConvertOptions {
+	opts := kaalin.ConvertOptions{
+		To:           to,
+		Dictionary:   dictionary,
+		From:         fromAlphabet,
+		Format:       docFormat,
+		FrontMatter:  frontMatter,
+		Paths:        valuePaths,
+		Columns:      columns,
+		AppendSuffix: colSuffix,
+		MarkFuzzy:    markFuzzy,
+		PerSegment:   perSegment,
+		Lookalikes:   normalize,
+	}
+	if toAlphabet.Script() == to {
+		opts.ToAlphabet = toAlphabet
+	}
+	return opts
+}
+
+// parseAlphabets reads --from-alphabet and --to-alphabet and checks them
+// against --to-cyr/--to-lat.
+func parseAlphabets() error {
+	var err error
+	if fromAlphabetName != "" {
+		if fromAlphabet, err = kaalin.ParseAlphabet(fromAlphabetName); err != nil {
+			return fmt.Errorf("--from-alphabet: %s", err)
+		}
+	}
+	if toAlphabetName != "" {
+		if toAlphabet, err = kaalin.ParseAlphabet(toAlphabetName); err != nil {
+			return fmt.Errorf("--to-alphabet: %s", err)
+		}
+		if (toCyr && toAlphabet.Script() != kaalin.Cyrillic) || (toLat && toAlphabet.Script() != kaalin.Latin) {
+			return fmt.Errorf("--to-alphabet %s contradicts --to-cyr/--to-lat", toAlphabet)
+		}
+	}
+	return nil
+}
+
+// loadDictionary combines the built-in exception list with the
+// dictionaries named in the config file and by --dict, later ones taking
+// precedence; "builtin" names the built-in list alone. It returns nil
+// with --no-dict or when no dictionary is named, so that the letter
+// tables alone are used.
+func loadDictionary() (*kaalin.Dictionary, error) {
+	if noDict {
+		return nil, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %s", err)
+	}
+	if cfg.Dict == "" && dictFile == "" {
+		return nil, nil
+	}
+
+	d := kaalin.DefaultDictionary()
+	for _, path := range []string{cfg.Dict, dictFile} {
+		if path == "" || path == config.BuiltinDict {
+			continue
+		}
+		user, err := kaalin.LoadDictionary(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dictionary: %s", err)
+		}
+		d.Merge(user)
+	}
+	return d, nil
+}
+
+// targetScript maps the --to-cyr/--to-lat flags to a conversion target.
+func targetScript() kaalin.Script {
+	switch {
+	case toAlphabet != kaalin.AlphabetUnknown:
+		return toAlphabet.Script()
+	case toCyr:
+		return kaalin.Cyrillic
+	case toLat:
+		return kaalin.Latin
+	}
+	return kaalin.Unknown
+}
+
 func interactiveInput() (string, error) {
 	direction := "Cyrillic"
 	if toLat {
@@ -152,5 +507,30 @@ func init() {
 	convertCmd.Flags().BoolVarP(&toLat, "to-lat", "l", false, "Convert to Latin")
 	convertCmd.Flags().StringVarP(&file, "file", "f", "", "Input file")
 	convertCmd.Flags().StringVarP(&outFile, "output", "o", "", "Output file")
-	convertCmd.Flags().BoolVar(&inPlace, "in-place", false, "Edit file in place (requires --file)")
+	convertCmd.Flags().BoolVar(&inPlace, "in-place", false, "Edit files in place (requires --file or --dir)")
+	convertCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show a diff of in-place changes without writing")
+	convertCmd.Flags().BoolVar(&check, "check", false, "Exit with status 1 if in-place conversion would change a file")
+	convertCmd.Flags().StringVar(&backupSuffix, "backup", "", "Keep the original of in-place edits with this suffix")
+	convertCmd.Flags().StringVar(&fromAlphabetName, "from-alphabet", "", "Input alphabet: latin, latin-old (1994/2009 apostrophes) or cyrillic")
+	convertCmd.Flags().StringVar(&toAlphabetName, "to-alphabet", "", "Output alphabet: latin, latin-old or cyrillic")
+	convertCmd.Flags().StringVar(&dictFile, "dict", "", "Exception dictionary file (TSV or YAML) over the built-in list, or \"builtin\" for the list alone")
+	convertCmd.Flags().BoolVar(&noDict, "no-dict", false, "Disable exception dictionaries, including one set in the config file")
+	convertCmd.Flags().StringVar(&losslessFile, "lossless", "", "Write a sidecar file that allows the exact original to be restored")
+	convertCmd.Flags().StringVar(&restoreFile, "restore", "", "Restore the original of a --lossless conversion from its sidecar file")
+	convertCmd.Flags().StringVar(&inputEncodingName, "input-encoding", "auto", "Input encoding: auto, utf-8, utf-16, utf-16le, utf-16be, windows-1251 or koi8-r")
+	convertCmd.Flags().StringVar(&outputEncodingName, "output-encoding", "", "Output encoding (default utf-8, or the file's own with --in-place)")
+	convertCmd.Flags().StringVar(&formatName, "format", "text", "Document format: text, html, xml, markdown, srt, vtt, json, yaml, csv, tsv, po, xliff, docx, odt or epub")
+	convertCmd.Flags().StringSliceVar(&valuePaths, "path", nil, "JSON/YAML values to convert, e.g. '$.messages.*' (repeatable)")
+	convertCmd.Flags().StringSliceVar(&columns, "columns", nil, "CSV/TSV columns to convert, by header name or 1-based number")
+	convertCmd.Flags().StringVar(&colSuffix, "append-suffix", "", "Keep CSV/TSV columns and append converted copies, e.g. _cyr")
+	convertCmd.Flags().BoolVar(&markFuzzy, "fuzzy", false, "Mark converted PO entries fuzzy and XLIFF targets as needing review")
+	convertCmd.Flags().StringSliceVar(&frontMatter, "front-matter", nil, "Markdown front-matter fields to convert, e.g. title,description")
+	convertCmd.Flags().BoolVar(&normalize, "normalize", false, "Read look-alike letters such as ğ, ñ and һ as ǵ, ń and ҳ")
+	convertCmd.Flags().BoolVar(&showSubstitutions, "show-substitutions", false, "Report decomposed accents and look-alike letters replaced before conversion")
+	convertCmd.Flags().BoolVar(&perSegment, "auto-per-segment", false, "Convert only the passages not already in the target script")
+	convertCmd.Flags().StringVar(&dir, "dir", "", "Convert every file under a directory")
+	convertCmd.Flags().StringVar(&outDir, "out-dir", "", "Output directory for --dir")
+	convertCmd.Flags().StringSliceVar(&includes, "include", nil, "Only convert files matching these globs (with --dir)")
+	convertCmd.Flags().StringSliceVar(&excludes, "exclude", nil, "Skip files matching these globs (with --dir)")
+	convertCmd.Flags().IntVar(&jobs, "jobs", runtime.NumCPU(), "Number of files converted concurrently (with --dir)")
 }
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/dontbeidle/kaalin/internal/batch"
+	"github.com/dontbeidle/kaalin/internal/fsutil"
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+// binarySniffSize is how much of a file is checked for NUL bytes before
+// it is treated as binary and skipped.
+const binarySniffSize = 8000
+
+// runDirConvert converts every selected file under --dir, either into
+// --out-dir (keeping the directory structure) or in place.
+func runDirConvert() {
+	out := outDir
+	if inPlace {
+		out = ""
+	}
+
+	tasks, filtered, err := batch.Plan(dir, batch.Options{
+		Include: includes,
+		Exclude: excludes,
+		OutDir:  out,
+	})
+	if err != nil {
+		output.Error(err.Error(), "")
+		os.Exit(1)
+	}
+
+	to := targetScript()
+	preview := dryRun || check
+
+	var (
+		mu      sync.Mutex
+		changed []string
+		patches = map[string]string{}
+	)
+	summary := batch.Run(tasks, jobs, func(task batch.Task) error {
+		ok, patch, err := convertFile(task, to)
+		if ok && preview {
+			mu.Lock()
+			changed = append(changed, task.Rel)
+			patches[task.Rel] = patch
+			mu.Unlock()
+		}
+		if err == nil && !ok {
+			return batch.ErrUnchanged
+		}
+		return err
+	})
+	summary.Skipped += filtered
+	sort.Strings(changed)
+
+	if output.JSONOutput {
+		failed := make([]map[string]string, 0, len(summary.Failed))
+		for _, f := range summary.Failed {
+			failed = append(failed, map[string]string{"path": f.Path, "error": f.Err.Error()})
+		}
+		result := map[string]interface{}{
+			"converted": summary.Converted,
+			"unchanged": summary.Unchanged,
+			"skipped":   summary.Skipped,
+			"failed":    failed,
+		}
+		if preview {
+			delete(result, "converted")
+			files := make([]map[string]string, 0, len(changed))
+			for _, rel := range changed {
+				files = append(files, map[string]string{"path": rel, "diff": patches[rel]})
+			}
+			result["changed"] = files
+		}
+		output.PrintJSON(result)
+	} else {
+		if dryRun {
+			for _, rel := range changed {
+				output.Diff(patches[rel])
+			}
+		}
+		for _, f := range summary.Failed {
+			output.Error(fmt.Sprintf("%s: %s", f.Path, f.Err), encodingHint(f.Err))
+		}
+		if preview {
+			output.Success(fmt.Sprintf("%d files would change, skipped %d, failed %d",
+				len(changed), summary.Skipped, len(summary.Failed)))
+		} else {
+			output.Success(fmt.Sprintf("Converted %d files, unchanged %d, skipped %d, failed %d",
+				summary.Converted, summary.Unchanged, summary.Skipped, len(summary.Failed)))
+		}
+	}
+
+	if len(summary.Failed) > 0 || (check && len(changed) > 0) {
+		os.Exit(1)
+	}
+}
+
+// convertFile streams task.Src into task.Dst. Binary files are skipped
+// unless the format is a binary document format or they are UTF-16 text.
+// In place, it follows updateInPlace and reports whether the file
+// changes and the --dry-run diff.
+func convertFile(task batch.Task, to kaalin.Script) (bool, string, error) {
+	f, err := os.Open(task.Src)
+	if err != nil {
+		return false, "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, "", err
+	}
+	if bytes.IndexByte(head[:n], 0) >= 0 && !docFormat.IsBinary() && !isUTF16Input(head[:n]) {
+		return false, "", batch.ErrSkip
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, "", err
+	}
+
+	if to, err = fileTarget(f, to); err != nil {
+		return false, "", err
+	}
+
+	if task.Dst == task.Src {
+		return updateInPlace(f, task.Src, to)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(task.Dst), 0755); err != nil {
+		return false, "", err
+	}
+	// A failed conversion leaves an existing output file as it was.
+	if err := fsutil.WriteAtomic(task.Dst, "", func(w io.Writer) error {
+		return streamConvert(w, f, to, false, false)
+	}); err != nil {
+		return false, "", err
+	}
+	return true, "", nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dontbeidle/kaalin/internal/batch"
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+func TestConvertFileOutDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.txt")
+	dst := filepath.Join(dir, "out", "in.txt")
+	task := batch.Task{Src: src, Dst: dst, Rel: "in.txt"}
+
+	if err := os.WriteFile(src, []byte("Сәлем\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := convertFile(task, kaalin.Latin); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "Sálem\n" {
+		t.Errorf("output = %q, want %q", got, "Sálem\n")
+	}
+
+	// Invalid UTF-8 after the sniffed head fails the conversion midway,
+	// which leaves the output as it was.
+	if err := os.WriteFile(src, []byte(strings.Repeat("Сәлем\n", 2000)+"\xFF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := convertFile(task, kaalin.Latin); err == nil {
+		t.Fatal("convertFile succeeded on invalid input")
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "Sálem\n" {
+		t.Errorf("output after a failure = %q, want %q", got, "Sálem\n")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dst))
+	if len(entries) != 1 {
+		t.Errorf("output directory holds %d entries, want 1", len(entries))
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dontbeidle/kaalin/internal/charset"
+	"golang.org/x/text/transform"
+)
+
+// encodingSniffSize is how much input is read to guess its encoding.
+const encodingSniffSize = 8192
+
+var (
+	inputEncodingName  string
+	outputEncodingName string
+
+	// inputEncoding is nil when the encoding of each input is detected.
+	inputEncoding *charset.Encoding
+	// outputEncoding is nil when output is UTF-8, or in place, in the
+	// encoding the file was read in.
+	outputEncoding *charset.Encoding
+)
+
+// parseEncodings reads --input-encoding and --output-encoding.
+func parseEncodings() error {
+	if inputEncodingName != "" && inputEncodingName != "auto" {
+		e, err := charset.Lookup(inputEncodingName)
+		if err != nil {
+			return fmt.Errorf("--input-encoding: %s", err)
+		}
+		inputEncoding = &e
+	}
+	if outputEncodingName != "" {
+		e, err := charset.Lookup(outputEncodingName)
+		if err != nil {
+			return fmt.Errorf("--output-encoding: %s", err)
+		}
+		outputEncoding = &e
+	}
+	return nil
+}
+
+// decodeInput returns src decoded to UTF-8 along with the encoding it was
+// read in. Input is validated as it is read, including UTF-8 beyond the
+// sniffed head, and fails with a *charset.DecodeError naming the offset
+// of the first invalid byte. Binary documents are never decoded.
+func decodeInput(src io.Reader) (io.Reader, charset.Encoding, error) {
+	if docFormat.IsBinary() {
+		return src, charset.UTF8, nil
+	}
+	if inputEncoding != nil {
+		return transform.NewReader(src, inputEncoding.NewDecoder()), *inputEncoding, nil
+	}
+
+	br := bufio.NewReaderSize(src, encodingSniffSize)
+	head, err := br.Peek(encodingSniffSize)
+	if err != nil && err != io.EOF {
+		return nil, charset.UTF8, err
+	}
+	enc := charset.Sniff(head)
+	return transform.NewReader(br, enc.NewDecoder()), enc, nil
+}
+
+// readInput reads all of src as UTF-8 text.
+func readInput(src io.Reader) (string, error) {
+	r, _, err := decodeInput(src)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+// isUTF16Input reports whether input starting with head is read as
+// UTF-16, whose NUL bytes do not make it binary.
+func isUTF16Input(head []byte) bool {
+	if inputEncoding != nil {
+		return inputEncoding.IsUTF16()
+	}
+	return charset.Sniff(head).IsUTF16()
+}
+
+// outputEncodingFor returns the encoding of output converted from input
+// read in encoding in: --output-encoding, else the input's own encoding
+// when a file is rewritten in place, else UTF-8.
+func outputEncodingFor(in charset.Encoding) charset.Encoding {
+	switch {
+	case docFormat.IsBinary():
+		return charset.UTF8
+	case outputEncoding != nil:
+		return *outputEncoding
+	case inPlace:
+		return in
+	}
+	return charset.UTF8
+}
+
+// encodeOutput wraps w so that text written to it is encoded in enc. The
+// returned close function flushes w and reports any characters that enc
+// cannot represent; they are written as '?'.
+func encodeOutput(w io.Writer, enc charset.Encoding) (io.Writer, func() error) {
+	if enc.IsUTF8() {
+		return w, func() error { return nil }
+	}
+	e := enc.NewEncoder()
+	tw := transform.NewWriter(w, e)
+	return tw, func() error {
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return e.Err()
+	}
+}
+
+// encodeString encodes s in the output encoding.
+func encodeString(s string) ([]byte, error) {
+	enc := outputEncodingFor(charset.UTF8)
+	if enc.IsUTF8() {
+		return []byte(s), nil
+	}
+	e := enc.NewEncoder()
+	out, _, err := transform.String(e, s)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), e.Err()
+}
+
+// encodingHint suggests how to fix an input or output encoding error.
+func encodingHint(err error) string {
+	var (
+		de *charset.DecodeError
+		ee *charset.EncodeError
+	)
+	switch {
+	case errors.As(err, &de):
+		return "check the file or set --input-encoding (utf-8, utf-16, windows-1251 or koi8-r)"
+	case errors.As(err, &ee):
+		return "use --output-encoding utf-8 or utf-16, which represent every Karakalpak letter"
+	}
+	return ""
+}
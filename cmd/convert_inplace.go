@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dontbeidle/kaalin/internal/diff"
+	"github.com/dontbeidle/kaalin/internal/fsutil"
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+// runInPlace rewrites --file, or reports the planned change with
+// --dry-run and --check.
+func runInPlace(f *os.File, to kaalin.Script) error {
+	changed, patch, err := updateInPlace(f, file, to)
+	if err != nil {
+		return fmt.Errorf("failed to update file: %w", err)
+	}
+
+	switch {
+	case dryRun && output.JSONOutput:
+		output.PrintJSON(map[string]interface{}{"changed": changed, "diff": patch})
+	case dryRun && changed:
+		output.Diff(patch)
+	case !changed:
+		output.Success(fmt.Sprintf("No changes: %s", file))
+	case !check:
+		output.Success(fmt.Sprintf("File updated: %s", file))
+	}
+
+	if check && changed {
+		output.Error(fmt.Sprintf("%s would change", file), "run without --check to convert it")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// updateInPlace converts the file f opened from path and writes it back
+// atomically, keeping its mode and an optional --backup copy.
+//
+// With --dry-run nothing is written and the unified diff of the planned
+// change is returned; with --check nothing is written and only whether
+// the file would change is reported. A file whose conversion equals its
+// content is left alone, without a backup, and reported unchanged.
+func updateInPlace(f *os.File, path string, to kaalin.Script) (changed bool, patch string, err error) {
+	switch {
+	case dryRun:
+		old, err := readInput(f)
+		if err != nil {
+			return false, "", err
+		}
+		if showSubstitutions {
+			reportSubstitutions(substitutionsOf(old))
+		}
+		result, err := kaalin.Convert(old, convertOptions(to))
+		if err != nil {
+			return false, "", err
+		}
+		patch = diff.Unified("a/"+path, "b/"+path, old, result, diff.DefaultContext)
+		return patch != "", patch, nil
+
+	case check:
+		orig, err := os.Open(path)
+		if err != nil {
+			return false, "", err
+		}
+		defer orig.Close()
+
+		cmp := &compareWriter{r: bufio.NewReader(orig)}
+		if err := streamConvert(cmp, f, to, false, false); err != nil {
+			return false, "", err
+		}
+		changed, err := cmp.changed()
+		return changed, "", err
+	}
+
+	orig, err := os.Open(path)
+	if err != nil {
+		return false, "", err
+	}
+	defer orig.Close()
+
+	err = fsutil.WriteAtomic(path, backupSuffix, func(w io.Writer) error {
+		cmp := &compareWriter{r: bufio.NewReader(orig)}
+		if err := streamConvert(io.MultiWriter(w, cmp), f, to, false, false); err != nil {
+			return err
+		}
+		changed, err := cmp.changed()
+		if err == nil && !changed {
+			// Failing the write drops the temporary file.
+			return errUnchanged
+		}
+		return err
+	})
+	if errors.Is(err, errUnchanged) {
+		return false, "", nil
+	}
+	return err == nil, "", err
+}
+
+// errUnchanged stops an in-place write whose output equals the file.
+var errUnchanged = errors.New("unchanged")
+
+// compareWriter records whether the bytes written to it differ from the
+// content of r, without buffering either side.
+type compareWriter struct {
+	r       *bufio.Reader
+	differs bool
+}
+
+func (c *compareWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 && !c.differs {
+		chunk := min(len(p), c.r.Size())
+		want, err := c.r.Peek(chunk)
+		if !bytes.Equal(want, p[:len(want)]) || (err != nil && len(want) < chunk) {
+			c.differs = true
+			break
+		}
+		c.r.Discard(chunk)
+		p = p[chunk:]
+	}
+	return n, nil
+}
+
+// changed reports whether the written bytes differ from r, including r
+// having data left over.
+func (c *compareWriter) changed() (bool, error) {
+	if c.differs {
+		return true, nil
+	}
+	_, err := c.r.Peek(1)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+func TestCompareWriter(t *testing.T) {
+	tests := []struct {
+		orig    string
+		written string
+		want    bool
+	}{
+		{"", "", false},
+		{"Sálem\n", "Sálem\n", false},
+		{"Sálem\n", "Сәлем\n", true},
+		{"Sálem", "Sálem\n", true},
+		{"Sálem\n", "Sálem", true},
+		{strings.Repeat("a", 10000), strings.Repeat("a", 10000), false},
+		{strings.Repeat("a", 10000), strings.Repeat("a", 9999) + "b", true},
+	}
+
+	for _, tt := range tests {
+		cmp := &compareWriter{r: bufio.NewReaderSize(iotest.HalfReader(strings.NewReader(tt.orig)), 16)}
+		for _, chunk := range chunks(tt.written, 7) {
+			if _, err := cmp.Write([]byte(chunk)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := cmp.changed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("compareWriter(%.10q, %.10q) changed = %v, want %v", tt.orig, tt.written, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateInPlaceUnchanged(t *testing.T) {
+	backupSuffix = ".bak"
+	t.Cleanup(func() { backupSuffix = "" })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	for _, tt := range []struct {
+		content string
+		changed bool
+		want    string
+		files   int // in the directory afterwards, backup included
+	}{
+		{"Сәлем\n", false, "Сәлем\n", 1},
+		{"Sálem\n", true, "Сәлем\n", 2},
+	} {
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(path + backupSuffix)
+		before, _ := os.Stat(path)
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed, _, err := updateInPlace(f, path, kaalin.Cyrillic)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != tt.changed {
+			t.Errorf("updateInPlace(%q) changed = %v, want %v", tt.content, changed, tt.changed)
+		}
+		if got, _ := os.ReadFile(path); string(got) != tt.want {
+			t.Errorf("updateInPlace(%q) wrote %q, want %q", tt.content, got, tt.want)
+		}
+
+		after, _ := os.Stat(path)
+		_, backupErr := os.Stat(path + backupSuffix)
+		if !tt.changed && (!os.SameFile(before, after) || backupErr == nil) {
+			t.Errorf("updateInPlace(%q) replaced the file or left a backup", tt.content)
+		}
+		if tt.changed && backupErr != nil {
+			t.Errorf("updateInPlace(%q) left no backup: %v", tt.content, backupErr)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != tt.files {
+			t.Errorf("updateInPlace(%q) left %d files in the directory", tt.content, len(entries))
+		}
+	}
+}
+
+func chunks(s string, n int) []string {
+	var out []string
+	for len(s) > n {
+		out = append(out, s[:n])
+		s = s[n:]
+	}
+	return append(out, s)
+}
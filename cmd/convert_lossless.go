@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+// losslessConvert converts text and writes the sidecar that --restore
+// needs to get the exact original back to --lossless.
+func losslessConvert(text string) (string, error) {
+	result, rt, err := kaalin.ConvertLossless(text, convertOptions(targetScript()))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(rt, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(losslessFile, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write sidecar: %s", err)
+	}
+	return result, nil
+}
+
+// restoreText reverses a --lossless conversion using the sidecar named by
+// --restore.
+func restoreText(text string) (string, error) {
+	data, err := os.ReadFile(restoreFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read sidecar: %s", err)
+	}
+
+	var rt kaalin.RoundTrip
+	if err := json.Unmarshal(data, &rt); err != nil {
+		return "", fmt.Errorf("%s: %s", restoreFile, err)
+	}
+
+	result, err := kaalin.Restore(text, &rt, dictionary)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", restoreFile, err)
+	}
+	return result, nil
+}
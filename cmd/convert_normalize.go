@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+	"golang.org/x/text/transform"
+)
+
+var (
+	showSubstitutions bool
+	// normalize replaces look-alike letters before conversion.
+	normalize bool
+)
+
+// newNormalizer returns a Normalizer making the replacements conversion
+// makes: look-alike letters are replaced only with --normalize.
+func newNormalizer() *kaalin.Normalizer {
+	n := &kaalin.Normalizer{}
+	n.Lookalikes = normalize
+	return n
+}
+
+// substitutionsOf returns the replacements conversion makes in text.
+func substitutionsOf(text string) []kaalin.Substitution {
+	n := newNormalizer()
+	if _, _, err := transform.String(n, text); err != nil {
+		return nil
+	}
+	return n.Substitutions()
+}
+
+// reportSubstitutions lists the characters that were composed or replaced
+// by look-alike letters before conversion.
+func reportSubstitutions(subs []kaalin.Substitution) {
+	if output.JSONOutput {
+		output.PrintJSON(map[string]interface{}{"substitutions": subs})
+		return
+	}
+	if len(subs) == 0 {
+		output.Note("No characters were normalized")
+		return
+	}
+	for _, s := range subs {
+		kind := "composed"
+		if s.Lookalike {
+			kind = "look-alike"
+		}
+		output.Note(fmt.Sprintf("%d:%d  %s → %s  (%s)", s.Line, s.Column, spellRunes(s.From), s.To, kind))
+	}
+}
+
+// spellRunes writes the combining marks of s as code points, so that a
+// decomposed letter can be told from the letter it was composed into.
+func spellRunes(s string) string {
+	var parts []string
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			parts = append(parts, fmt.Sprintf("U+%04X", r))
+		} else {
+			parts = append(parts, string(r))
+		}
+	}
+	return strings.Join(parts, " ")
+}
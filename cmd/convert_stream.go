@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dontbeidle/kaalin/internal/fsutil"
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+	"golang.org/x/text/transform"
+)
+
+// runStreamConvert converts piped stdin or --file chunk by chunk, so memory
+// use does not grow with the input size. It reports false when there is no
+// streamable input and the caller should fall back to interactive mode.
+//
+// The output matches the whole-buffer conversion byte for byte: stdin has
+// its trailing newlines trimmed, and results printed to stdout end with a
+// single newline. Binary documents are passed through as they are.
+func runStreamConvert() (bool, error) {
+	var src io.Reader
+	var srcFile *os.File
+	trim := false
+
+	// --in-place always reads the file it rewrites.
+	stat, _ := os.Stdin.Stat()
+	switch {
+	case !inPlace && (stat.Mode()&os.ModeCharDevice) == 0:
+		src, trim = os.Stdin, !docFormat.IsBinary()
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return true, fmt.Errorf("failed to read file: %s", err)
+		}
+		defer f.Close()
+		src, srcFile = f, f
+	default:
+		return false, nil
+	}
+
+	to := targetScript()
+	if srcFile != nil {
+		var err error
+		if to, err = fileTarget(srcFile, to); err != nil {
+			return true, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	switch {
+	case inPlace:
+		return true, runInPlace(srcFile, to)
+
+	case outFile != "" && !(srcFile != nil && sameFile(outFile, file)):
+		f, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return true, fmt.Errorf("failed to write file: %s", err)
+		}
+		if err := streamConvert(f, src, to, trim, false); err != nil {
+			f.Close()
+			return true, fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return true, fmt.Errorf("failed to write file: %s", err)
+		}
+		output.Success(fmt.Sprintf("Converted: %s", outFile))
+
+	case outFile != "":
+		// Writing over the input while reading it would truncate it, so
+		// go through a temporary file.
+		if err := fsutil.WriteAtomic(outFile, "", func(w io.Writer) error {
+			return streamConvert(w, src, to, trim, false)
+		}); err != nil {
+			return true, fmt.Errorf("failed to write file: %w", err)
+		}
+		output.Success(fmt.Sprintf("Converted: %s", outFile))
+
+	default:
+		if err := streamConvert(os.Stdout, src, to, trim, !docFormat.IsBinary()); err != nil {
+			return true, fmt.Errorf("conversion failed: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// fileTarget resolves an Unknown target for f. Files can be read twice,
+// so the script is detected over the whole file exactly as the
+// whole-buffer conversion would, and f is rewound afterwards. Documents
+// are left to the conversion, which detects the script of their text.
+func fileTarget(f *os.File, to kaalin.Script) (kaalin.Script, error) {
+	if to != kaalin.Unknown || docFormat != kaalin.FormatText {
+		return to, nil
+	}
+	r, _, err := decodeInput(f)
+	if err != nil {
+		return to, err
+	}
+	script, err := kaalin.DetectScriptReader(r)
+	if err != nil {
+		return to, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return to, err
+	}
+	if script == kaalin.Cyrillic {
+		return kaalin.Latin, nil
+	}
+	return kaalin.Cyrillic, nil
+}
+
+// streamConvert converts src into w, decoding and encoding it as
+// --input-encoding and --output-encoding say. trim drops trailing
+// newlines from the input and newline appends one to the output. With
+// --show-substitutions the normalized characters are reported at the end.
+func streamConvert(w io.Writer, src io.Reader, to kaalin.Script, trim, newline bool) error {
+	src, in, err := decodeInput(src)
+	if err != nil {
+		return err
+	}
+	var norm *kaalin.Normalizer
+	if showSubstitutions {
+		norm = newNormalizer()
+		src = transform.NewReader(src, norm)
+	}
+	if trim {
+		src = &trimNewlineReader{r: src}
+	}
+
+	out, closeOut := encodeOutput(w, outputEncodingFor(in))
+	bw := bufio.NewWriter(out)
+	if err := kaalin.ConvertStream(bw, src, convertOptions(to)); err != nil {
+		return err
+	}
+	if newline {
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := closeOut(); err != nil {
+		return err
+	}
+	if norm != nil {
+		reportSubstitutions(norm.Substitutions())
+	}
+	return nil
+}
+
+func sameFile(a, b string) bool {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(sa, sb)
+}
+
+// trimNewlineReader yields the data of r without its trailing newlines,
+// like strings.TrimRight(data, "\n"). Newlines are held back only until
+// more non-newline data arrives.
+type trimNewlineReader struct {
+	r    io.Reader
+	buf  []byte
+	out  []byte
+	held int
+	err  error
+}
+
+func (t *trimNewlineReader) Read(p []byte) (int, error) {
+	if t.buf == nil {
+		t.buf = make([]byte, 32*1024)
+	}
+
+	for len(t.out) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+
+		n, err := t.r.Read(t.buf)
+		t.err = err
+
+		body := bytes.TrimRight(t.buf[:n], "\n")
+		if len(body) > 0 {
+			t.out = append(t.out[:0], bytes.Repeat([]byte{'\n'}, t.held)...)
+			t.out = append(t.out, body...)
+			t.held = 0
+		}
+		t.held += n - len(body)
+	}
+
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
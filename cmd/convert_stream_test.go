@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dontbeidle/kaalin/internal/charset"
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+// wholeBuffer reproduces the output of the non-streaming conversion path.
+func wholeBuffer(input string, to kaalin.Script, trim, newline bool) string {
+	if trim {
+		input = strings.TrimRight(input, "\n")
+	}
+	result, _ := kaalin.Convert(input, kaalin.ConvertOptions{To: to})
+	if newline {
+		result += "\n"
+	}
+	return result
+}
+
+func TestStreamConvertMatchesWholeBuffer(t *testing.T) {
+	inputs := []string{
+		"",
+		"\n\n\n",
+		"Sálem",
+		"Sálem\n",
+		"Sálem\n\n\n",
+		"Sálem\n\nShahar\n\n",
+		"обьиктив объект\r\nкальций\n",
+		strings.Repeat("Assalawma áleykum, dun'ya! SHAHAR\n", 20000),
+		strings.Repeat("Ассалаўма әлейкум, объект\n\n", 20000) + "\n\n",
+		// Latin for far more than a detection prefix, then mostly Cyrillic.
+		strings.Repeat("Sálem dunya\n", 8000) + strings.Repeat("Сәлем дүнья\n", 20000),
+	}
+
+	for _, input := range inputs {
+		for _, to := range []kaalin.Script{kaalin.Cyrillic, kaalin.Latin, kaalin.Unknown} {
+			for _, trim := range []bool{false, true} {
+				for _, newline := range []bool{false, true} {
+					want := wholeBuffer(input, to, trim, newline)
+
+					var buf bytes.Buffer
+					src := iotest.OneByteReader(strings.NewReader(input))
+					if len(input) > 1000 {
+						src = iotest.HalfReader(strings.NewReader(input))
+					}
+					if err := streamConvert(&buf, src, to, trim, newline); err != nil {
+						t.Fatalf("streamConvert returned error: %v", err)
+					}
+					if buf.String() != want {
+						t.Errorf("streamConvert(%.20q, %v, trim=%v, newline=%v) differs from whole-buffer output",
+							input, to, trim, newline)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStreamConvertEncodings(t *testing.T) {
+	t.Cleanup(func() { inPlace, outputEncoding = false, nil })
+
+	tests := []struct {
+		name    string
+		inPlace bool
+		output  string
+		input   string
+		want    string
+	}{
+		{"windows-1251 to utf-8", false, "", "\xD1\xE0\xEB\xE5\xEC", "Salem"},
+		{"koi8-r to utf-8", false, "", "\xF3\xC1\xCC\xC5\xCD \xC4\xD5\xCE\xD8\xD1", "Salem dunya"},
+		{"utf-16 kept in place", true, "", "\xFF\xFE\x21\x04\x30\x04", "\xFF\xFES\x00a\x00"},
+		{"utf-8 to utf-16be", false, "utf-16be", "Са", "\x00S\x00a"},
+		{"utf-8 bom to windows-1251", false, "cp1251", "\xEF\xBB\xBFСа", "Sa"},
+	}
+	for _, tt := range tests {
+		inPlace, outputEncoding = tt.inPlace, nil
+		if tt.output != "" {
+			e, err := charset.Lookup(tt.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputEncoding = &e
+		}
+		var buf bytes.Buffer
+		if err := streamConvert(&buf, strings.NewReader(tt.input), kaalin.Latin, false, false); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestDecodeInputInvalidUTF8(t *testing.T) {
+	// The invalid byte comes after the head that is sniffed as UTF-8.
+	input := strings.Repeat("Sálem dunya\n", 1000) + "\xFF\n"
+	offset := int64(len(input) - 2)
+
+	_, err := readInput(strings.NewReader(input))
+	var de *charset.DecodeError
+	if !errors.As(err, &de) || de.Offset != offset {
+		t.Errorf("readInput: err = %v, want a decode error at byte %d", err, offset)
+	}
+
+	var buf bytes.Buffer
+	err = streamConvert(&buf, strings.NewReader(input), kaalin.Cyrillic, false, false)
+	if !errors.As(err, &de) || de.Offset != offset {
+		t.Errorf("streamConvert: err = %v, want a decode error at byte %d", err, offset)
+	}
+}
+
+func TestStreamConvertUnrepresentable(t *testing.T) {
+	e := charset.Windows1251
+	outputEncoding = &e
+	t.Cleanup(func() { outputEncoding = nil })
+
+	var buf bytes.Buffer
+	err := streamConvert(&buf, strings.NewReader("sol\nqala"), kaalin.Cyrillic, false, false)
+	var ee *charset.EncodeError
+	if !errors.As(err, &ee) || len(ee.Chars) != 1 || ee.Chars[0].Line != 2 || ee.Chars[0].Column != 1 {
+		t.Fatalf("err = %v, want қ reported at 2:1", err)
+	}
+	if buf.String() != "\xF1\xEE\xEB\n?\xE0\xEB\xE0" {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestTrimNewlineReader(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"\n", ""},
+		{"a\n", "a"},
+		{"a\n\nb\n\n", "a\n\nb"},
+		{"a\r\n", "a\r"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		r := &trimNewlineReader{r: iotest.OneByteReader(strings.NewReader(tt.input))}
+		if _, err := buf.ReadFrom(r); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("trimNewlineReader(%q) = %q, want %q", tt.input, buf.String(), tt.want)
+		}
+	}
+}
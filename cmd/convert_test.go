@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dontbeidle/kaalin/internal/config"
+	"github.com/dontbeidle/kaalin/kaalin"
+)
+
+func TestLoadDictionary(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	words := filepath.Join(dir, "words.tsv")
+	if err := os.WriteFile(words, []byte("Nókis\tНөкис-қала\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvPath, cfgPath)
+	t.Cleanup(func() { dictFile, noDict = "", false })
+
+	tests := []struct {
+		name   string
+		config string
+		dict   string
+		noDict bool
+		want   string
+	}{
+		{"letter tables by default", "", "", false, "июн Нөкис"},
+		{"builtin", "", "builtin", false, "июнь Нөкис"},
+		{"file over builtin", "", words, false, "июнь Нөкис-қала"},
+		{"config", "dict: builtin\n", "", false, "июнь Нөкис"},
+		{"no-dict overrides config", "dict: builtin\n", "", true, "июн Нөкис"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(cfgPath, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dictFile, noDict = tt.dict, tt.noDict
+		d, err := loadDictionary()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := kaalin.Convert("iyun Nókis", kaalin.ConvertOptions{To: kaalin.Cyrillic, Dictionary: d})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Convert = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+	"github.com/spf13/cobra"
+)
+
+// detectPreviewLen is how many characters of each segment the text
+// output shows.
+const detectPreviewLen = 40
+
+var detectFile string
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [text]",
+	Short: "Detect the script of each passage",
+	Long: `Split text into passages written in Latin or Cyrillic.
+
+Each segment is reported with its script, a confidence score (the share of
+its letters written in that script) and its character offsets:
+  kaalin detect "Сәлем, hello"
+  kaalin detect -f mixed.txt --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text, err := getDetectInput(args)
+		if err != nil {
+			output.Error(err.Error(), "")
+			os.Exit(2)
+		}
+
+		segs := kaalin.DetectSegments(text)
+
+		if output.JSONOutput {
+			result := make([]map[string]interface{}, 0, len(segs))
+			chars := 0
+			for _, s := range segs {
+				part := text[s.Start:s.End]
+				n := utf8.RuneCountInString(part)
+				result = append(result, map[string]interface{}{
+					"start":      chars,
+					"end":        chars + n,
+					"script":     s.Script.String(),
+					"confidence": s.Confidence,
+					"text":       part,
+				})
+				chars += n
+			}
+			output.PrintJSON(map[string]interface{}{
+				"script":   kaalin.DetectScript(text).String(),
+				"segments": result,
+			})
+			return nil
+		}
+
+		chars := 0
+		for _, s := range segs {
+			part := text[s.Start:s.End]
+			n := utf8.RuneCountInString(part)
+			output.ResultLn(fmt.Sprintf("%d-%d\t%s\t%.2f\t%s",
+				chars, chars+n, s.Script, s.Confidence, preview(part)))
+			chars += n
+		}
+		return nil
+	},
+}
+
+// preview quotes the start of a segment on a single line.
+func preview(text string) string {
+	if utf8.RuneCountInString(text) > detectPreviewLen {
+		text = string([]rune(text)[:detectPreviewLen]) + "…"
+	}
+	return fmt.Sprintf("%q", text)
+}
+
+func getDetectInput(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+
+	if detectFile != "" {
+		data, err := os.ReadFile(detectFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %s", err)
+		}
+		return string(data), nil
+	}
+
+	return getCaseInput(args)
+}
+
+func init() {
+	detectCmd.Flags().StringVarP(&detectFile, "file", "f", "", "Input file")
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/dontbeidle/kaalin/internal/fsutil"
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+	"github.com/spf13/cobra"
+)
+
+// lintRule is the SARIF rule id of mixed-script words.
+const lintRule = "mixed-script"
+
+var (
+	lintFix   bool
+	lintSARIF bool
+)
+
+// lintProblem is a mixed-script word found in a file.
+type lintProblem struct {
+	Path string `json:"path"`
+	kaalin.MixedWord
+	Fixed bool `json:"fixed"`
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint FILE...",
+	Short: "Find words that mix Latin and Cyrillic letters",
+	Long: `Report words that mix Latin and Cyrillic letters, such as a Cyrillic word
+typed with a Latin a, o, e, c or x. Such words look right but break search.
+
+Each word is reported with its line and column and the spelling it is
+likely meant to have. --fix replaces the look-alike letters in place; words
+with letters that have no look-alike in the other script (ǵ, ғ) are only
+reported. The exit status is 1 while any word is left unfixed:
+  kaalin lint docs/*.txt
+  kaalin lint --fix notes.txt
+  kaalin lint --sarif docs/*.md > kaalin.sarif
+  cat notes.txt | kaalin lint --fix - > fixed.txt`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			output.Error("lint needs at least one file", "pass file names, or - to read stdin")
+			os.Exit(2)
+		}
+		if lintSARIF && output.JSONOutput {
+			output.Error("--sarif and --json cannot be used together", "choose only one")
+			os.Exit(2)
+		}
+		fixStdin := lintFix && slices.Contains(args, "-")
+		if fixStdin && (lintSARIF || output.JSONOutput) {
+			output.Error("--fix writes fixed stdin to stdout and cannot be combined with --json or --sarif", "lint files instead of -")
+			os.Exit(2)
+		}
+
+		var (
+			problems []lintProblem
+			failed   bool
+		)
+		for _, path := range args {
+			found, err := lintFile(path)
+			if err != nil {
+				output.Error(fmt.Sprintf("%s: %s", path, err), "")
+				failed = true
+				continue
+			}
+			problems = append(problems, found...)
+		}
+
+		remaining := 0
+		for _, p := range problems {
+			if !p.Fixed {
+				remaining++
+			}
+		}
+
+		switch {
+		case lintSARIF:
+			printSARIF(problems)
+		case output.JSONOutput:
+			output.PrintJSON(map[string]interface{}{
+				"problems":  append([]lintProblem{}, problems...),
+				"fixed":     len(problems) - remaining,
+				"remaining": remaining,
+			})
+		default:
+			printLintProblems(problems, fixStdin)
+			switch {
+			case remaining > 0:
+				output.Error(fmt.Sprintf("%d mixed-script %s", remaining, plural(remaining, "word")), lintHint())
+			case len(problems) > 0:
+				output.Success(fmt.Sprintf("Fixed %d mixed-script %s", len(problems), plural(len(problems), "word")))
+			case !failed:
+				output.Success("No mixed-script words")
+			}
+		}
+
+		if failed || remaining > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// lintFile reports the mixed-script words of the file at path, or of
+// stdin for "-". With --fix the file is rewritten atomically, and fixed
+// stdin is written to stdout.
+func lintFile(path string) ([]lintProblem, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("not valid UTF-8; convert it first with kaalin convert --input-encoding")
+	}
+	text := string(data)
+
+	words := kaalin.FindMixedScript(text)
+	problems := make([]lintProblem, len(words))
+	for i, w := range words {
+		problems[i] = lintProblem{Path: path, MixedWord: w, Fixed: lintFix && w.Fixable}
+	}
+	if !lintFix {
+		return problems, nil
+	}
+
+	fixed := kaalin.FixMixedScript(text)
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, fixed)
+		return problems, err
+	}
+	if fixed != text {
+		err = fsutil.WriteAtomic(path, "", func(w io.Writer) error {
+			_, err := io.WriteString(w, fixed)
+			return err
+		})
+	}
+	return problems, err
+}
+
+// printLintProblems lists problems as path:line:column messages. They go
+// to stderr when stdout carries fixed stdin.
+func printLintProblems(problems []lintProblem, toStderr bool) {
+	w := os.Stdout
+	if toStderr {
+		w = os.Stderr
+	}
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s:%d:%d: %s\n", p.Path, p.Line, p.Column, lintMessage(p))
+	}
+}
+
+func lintMessage(p lintProblem) string {
+	switch {
+	case p.Fixed:
+		return fmt.Sprintf("fixed %q → %q", p.Word, p.Suggestion)
+	case p.Fixable:
+		return fmt.Sprintf("%q mixes Latin and Cyrillic letters; did you mean %q?", p.Word, p.Suggestion)
+	}
+	return fmt.Sprintf("%q mixes Latin and Cyrillic letters; some have no %s look-alike", p.Word, p.Script)
+}
+
+func lintHint() string {
+	if lintFix {
+		return "retype the remaining words by hand"
+	}
+	return "run with --fix to replace look-alike letters"
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// printSARIF writes problems as a SARIF 2.1.0 log for code scanning
+// tools. Columns count Unicode code points.
+func printSARIF(problems []lintProblem) {
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+	type artifact struct {
+		URI string `json:"uri"`
+	}
+	type text struct {
+		Text string `json:"text"`
+	}
+
+	results := make([]map[string]interface{}, 0, len(problems))
+	for _, p := range problems {
+		loc := artifact{URI: p.Path}
+		reg := region{
+			StartLine:   p.Line,
+			StartColumn: p.Column,
+			EndLine:     p.Line,
+			EndColumn:   p.Column + utf8.RuneCountInString(p.Word),
+		}
+		result := map[string]interface{}{
+			"ruleId":  lintRule,
+			"level":   "warning",
+			"message": text{lintMessage(p)},
+			"locations": []interface{}{map[string]interface{}{
+				"physicalLocation": map[string]interface{}{"artifactLocation": loc, "region": reg},
+			}},
+		}
+		if p.Fixable && !p.Fixed {
+			result["fixes"] = []interface{}{map[string]interface{}{
+				"description": text{"Replace look-alike letters"},
+				"artifactChanges": []interface{}{map[string]interface{}{
+					"artifactLocation": loc,
+					"replacements": []interface{}{map[string]interface{}{
+						"deletedRegion":   reg,
+						"insertedContent": text{p.Suggestion},
+					}},
+				}},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{map[string]interface{}{
+			"tool": map[string]interface{}{"driver": map[string]interface{}{
+				"name":           "kaalin",
+				"version":        version,
+				"informationUri": "https://github.com/dontbeidle/kaalin",
+				"rules": []interface{}{map[string]interface{}{
+					"id":               lintRule,
+					"shortDescription": text{"Word mixes Latin and Cyrillic letters"},
+				}},
+			}},
+			"columnKind": "unicodeCodePoints",
+			"results":    results,
+		}},
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(log)
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Replace look-alike letters in the files")
+	lintCmd.Flags().BoolVar(&lintSARIF, "sarif", false, "Print a SARIF log for code scanning")
+}
@@ -1,14 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 	"strings"
 
-	"github.com/dontbeidle/kaalin/internal/number"
 	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
 	"github.com/spf13/cobra"
 )
 
@@ -16,19 +16,33 @@ var numberCmd = &cobra.Command{
 	Use:     "number <son>",
 	Short:   "Convert a number to words",
 	Aliases: []string{"n"},
-	Long: `Convert a number to Karakalpak words.
+	Long: `Convert a number to Karakalpak words. Decimals, common fractions,
+mixed numbers and percentages are read:
+  kaalin number 12.75
+  kaalin number 3/4
+  kaalin number 2 1/2
+  kaalin number 15%
 
-For negative numbers use --: kaalin number -- -5`,
+For negative numbers use --: kaalin number -- -5
+
+Flags:
+  -c, --cyr       Spell in Cyrillic
+  -o, --ordinal   Spell the ordinal: 6 → altınshı
+      --short     With --ordinal, write the short form: 25 → 25-`,
 	// Disable flag parsing to allow negative numbers like -5.
 	DisableFlagParsing: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Manually parse flags
-		var cyr bool
+		var cyr, ordinal, short bool
 		var cleanArgs []string
 		for i := 0; i < len(args); i++ {
 			switch args[i] {
 			case "--cyr", "-c":
 				cyr = true
+			case "--ordinal", "-o":
+				ordinal = true
+			case "--short":
+				short = true
 			case "--json", "-j":
 				output.JSONOutput = true
 			case "--no-color":
@@ -56,21 +70,45 @@ For negative numbers use --: kaalin number -- -5`,
 
 		input = strings.TrimSpace(input)
 
-		num, err := strconv.ParseFloat(input, 64)
-		if err != nil {
-			output.Error(
-				fmt.Sprintf("\"%s\" is not a valid number", input),
-				"enter an integer or decimal (e.g. 123, 12.75, -5)",
-			)
+		if short && !ordinal {
+			output.Error("--short needs --ordinal", "use --ordinal --short for the short form, e.g. 25-")
 			os.Exit(2)
 		}
 
-		script := "lat"
+		opts := kaalin.NumberOptions{Script: kaalin.Latin}
 		if cyr {
-			script = "cyr"
+			opts.Script = kaalin.Cyrillic
 		}
 
-		result, err := number.ToWord(num, script)
+		// The digits are passed through as typed, so none are lost to
+		// floating point.
+		var result string
+		switch {
+		case short:
+			result, err = kaalin.AbbreviateOrdinal(input)
+		case ordinal:
+			result, err = kaalin.OrdinalToWords(input, opts)
+		case strings.HasSuffix(input, "%"):
+			result, err = kaalin.PercentToWords(input, opts)
+		case strings.Contains(input, "/"):
+			result, err = kaalin.FractionToWords(input, opts)
+		default:
+			result, err = kaalin.DecimalToWords(input, opts)
+		}
+		if errors.Is(err, kaalin.ErrInvalidNumber) {
+			switch {
+			case ordinal:
+				output.Error(err.Error(), "enter a whole number (e.g. 1, 25, 1000)")
+			case strings.Contains(input, "/"):
+				output.Error(err.Error(), "enter a fraction or mixed number (e.g. 3/4, 2 1/2)")
+			default:
+				output.Error(
+					fmt.Sprintf("\"%s\" is not a valid number", input),
+					"enter an integer, decimal, fraction or percentage (e.g. 123, 12.75, 3/4, 15%)",
+				)
+			}
+			os.Exit(2)
+		}
 		if err != nil {
 			output.Error(err.Error(), "")
 			os.Exit(1)
@@ -86,9 +124,11 @@ For negative numbers use --: kaalin number -- -5`,
 	},
 }
 
+// getNumberInput joins the arguments, so that a mixed number such as
+// 2 1/2 need not be quoted, or reads piped stdin.
 func getNumberInput(args []string) (string, error) {
 	if len(args) > 0 {
-		return args[0], nil
+		return strings.Join(args, " "), nil
 	}
 
 	stat, _ := os.Stdin.Stat()
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/output"
+	"github.com/dontbeidle/kaalin/kaalin"
+	"github.com/spf13/cobra"
+)
+
+var parseNumberCmd = &cobra.Command{
+	Use:   "parse-number <words>",
+	Short: "Convert a number in words to digits",
+	Long: `Read a number spelled out in Karakalpak words, in Latin or Cyrillic, and
+print it in digits. It is the inverse of kaalin number:
+  kaalin parse-number bir júz jigirma úsh
+  kaalin parse-number "он еки пүтін жүзден жетпис бес"
+  kaalin parse-number minus bes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getWordsInput(args)
+		if err != nil {
+			output.Error(err.Error(), "")
+			os.Exit(2)
+		}
+
+		result, err := kaalin.WordsToNumber(input)
+		if err != nil {
+			output.Error(err.Error(), "spell the number as kaalin number prints it (e.g. bir júz jigirma úsh)")
+			os.Exit(2)
+		}
+
+		if output.JSONOutput {
+			output.PrintJSON(map[string]string{"result": result})
+		} else {
+			output.ResultLn(result)
+		}
+		return nil
+	},
+}
+
+// getWordsInput joins the arguments, so the words need not be quoted, or
+// reads piped stdin.
+func getWordsInput(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+
+	stat, _ := os.Stdin.Stat()
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %s", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return "", fmt.Errorf("no words provided")
+}
@@ -14,7 +14,9 @@ var rootCmd = &cobra.Command{
 
 Features:
   - Latin ↔ Cyrillic script conversion
-  - Number → words conversion
+  - Script detection for mixed-script text
+  - Linting of words that mix Latin and Cyrillic letters
+  - Number → words conversion, and words → number
   - Upper / Lower case (Karakalpak alphabet aware)`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		output.Init()
@@ -34,7 +36,10 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&output.Quiet, "quiet", "q", false, "Only print the result")
 
 	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(detectCmd)
+	rootCmd.AddCommand(lintCmd)
 	rootCmd.AddCommand(numberCmd)
+	rootCmd.AddCommand(parseNumberCmd)
 	rootCmd.AddCommand(caseCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(completionCmd)
@@ -5,6 +5,8 @@ go 1.22.0
 require (
 	github.com/fatih/color v1.18.0
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,238 @@
+// Package batch plans and runs conversions over directory trees.
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSkip can be returned by a Run callback to count a file as skipped
+// rather than failed, e.g. for binary files.
+var ErrSkip = errors.New("skipped")
+
+// ErrUnchanged can be returned by a Run callback to count a file whose
+// conversion left it as it was.
+var ErrUnchanged = errors.New("unchanged")
+
+// Task is a single file to convert.
+type Task struct {
+	Src string // path of the input file
+	Dst string // path of the output file; equal to Src when converting in place
+	Rel string // slash-separated path relative to the walked root
+}
+
+// Failure records a file that could not be converted.
+type Failure struct {
+	Path string
+	Err  error
+}
+
+// Summary counts the outcome of a batch run.
+type Summary struct {
+	Converted int
+	Unchanged int
+	Skipped   int
+	Failed    []Failure
+}
+
+// Options selects files in a tree.
+type Options struct {
+	Include []string // globs a file must match; empty means every file
+	Exclude []string // globs that remove a file even if included
+	OutDir  string   // mirror the tree here; empty means in place
+}
+
+// Match reports whether the slash-separated relative path rel matches
+// pattern. Patterns without a slash match the base name at any depth
+// ("*.txt"); others match the whole path, where "**" stands for any
+// number of directories ("vendor/**", "docs/**/*.md").
+func Match(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ValidatePatterns returns an error for the first malformed glob.
+func ValidatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		for _, seg := range strings.Split(p, "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %s", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Plan walks root and returns the files selected by opts, sorted by path,
+// together with the number of files filtered out. Hidden directories such
+// as .git and the output directory itself are never entered.
+func Plan(root string, opts Options) ([]Task, int, error) {
+	if err := ValidatePatterns(opts.Include); err != nil {
+		return nil, 0, err
+	}
+	if err := ValidatePatterns(opts.Exclude); err != nil {
+		return nil, 0, err
+	}
+
+	outAbs := ""
+	if opts.OutDir != "" {
+		var err error
+		if outAbs, err = filepath.Abs(opts.OutDir); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var tasks []Task
+	skipped := 0
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if outAbs != "" {
+				if abs, err := filepath.Abs(p); err == nil && abs == outAbs {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			skipped++
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !selected(rel, opts) {
+			skipped++
+			return nil
+		}
+
+		dst := p
+		if opts.OutDir != "" {
+			dst = filepath.Join(opts.OutDir, filepath.FromSlash(rel))
+		}
+		tasks = append(tasks, Task{Src: p, Dst: dst, Rel: rel})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Rel < tasks[j].Rel })
+	return tasks, skipped, nil
+}
+
+func selected(rel string, opts Options) bool {
+	if len(opts.Include) > 0 {
+		included := false
+		for _, p := range opts.Include {
+			if Match(p, rel) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, p := range opts.Exclude {
+		if Match(p, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+// Run calls convert for every task on a pool of workers goroutines and
+// summarises the results. Failures are sorted by path.
+func Run(tasks []Task, workers int, convert func(Task) error) Summary {
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan Task)
+	var (
+		mu      sync.Mutex
+		summary Summary
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				err := convert(task)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					summary.Converted++
+				case errors.Is(err, ErrUnchanged):
+					summary.Unchanged++
+				case errors.Is(err, ErrSkip):
+					summary.Skipped++
+				default:
+					summary.Failed = append(summary.Failed, Failure{Path: task.Rel, Err: err})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+	wg.Wait()
+
+	sort.Slice(summary.Failed, func(i, j int) bool {
+		return summary.Failed[i].Path < summary.Failed[j].Path
+	})
+	return summary
+}
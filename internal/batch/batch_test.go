@@ -0,0 +1,142 @@
+package batch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.txt", "a.txt", true},
+		{"*.txt", "docs/deep/a.txt", true},
+		{"*.txt", "a.md", false},
+		{"vendor/**", "vendor/a.txt", true},
+		{"vendor/**", "vendor/x/y/a.txt", true},
+		{"vendor/**", "src/vendor/a.txt", false},
+		{"**/vendor/**", "src/vendor/a.txt", true},
+		{"docs/**/*.md", "docs/a.md", true},
+		{"docs/**/*.md", "docs/x/y/a.md", true},
+		{"docs/**/*.md", "docs/x/a.txt", false},
+		{"docs/*.md", "docs/x/a.md", false},
+		{"./docs/*.md", "docs/a.md", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.rel, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.rel); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	if err := ValidatePatterns([]string{"*.txt", "vendor/**"}); err != nil {
+		t.Errorf("ValidatePatterns returned error for valid patterns: %v", err)
+	}
+	if err := ValidatePatterns([]string{"[a-"}); err == nil {
+		t.Error("ValidatePatterns([a-) should return error")
+	}
+}
+
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"a.txt",
+		"b.md",
+		"docs/c.txt",
+		"vendor/d.txt",
+		".git/config",
+		"out/e.txt",
+	)
+
+	tasks, skipped, err := Plan(root, Options{
+		Include: []string{"*.txt"},
+		Exclude: []string{"vendor/**"},
+		OutDir:  filepath.Join(root, "out"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rels []string
+	for _, task := range tasks {
+		rels = append(rels, task.Rel)
+	}
+	if got, want := strings.Join(rels, ","), "a.txt,docs/c.txt"; got != want {
+		t.Errorf("Plan selected %q, want %q", got, want)
+	}
+	if skipped != 2 {
+		t.Errorf("Plan skipped %d files, want 2", skipped)
+	}
+
+	want := filepath.Join(root, "out", "docs", "c.txt")
+	if tasks[1].Dst != want {
+		t.Errorf("Plan Dst = %q, want %q", tasks[1].Dst, want)
+	}
+}
+
+func TestPlanInPlace(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a.txt")
+
+	tasks, _, err := Plan(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Dst != tasks[0].Src {
+		t.Errorf("Plan in place = %+v, want Dst == Src", tasks)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var tasks []Task
+	for _, name := range []string{"ok1", "ok2", "ok3", "same", "skip", "fail2", "fail1"} {
+		tasks = append(tasks, Task{Src: name, Dst: name, Rel: name})
+	}
+
+	var calls int32
+	summary := Run(tasks, 3, func(task Task) error {
+		atomic.AddInt32(&calls, 1)
+		switch {
+		case task.Rel == "same":
+			return ErrUnchanged
+		case task.Rel == "skip":
+			return ErrSkip
+		case strings.HasPrefix(task.Rel, "fail"):
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if calls != int32(len(tasks)) {
+		t.Errorf("Run called convert %d times, want %d", calls, len(tasks))
+	}
+	if summary.Converted != 3 || summary.Unchanged != 1 || summary.Skipped != 1 || len(summary.Failed) != 2 {
+		t.Errorf("Run summary = %+v, want 3 converted, 1 unchanged, 1 skipped, 2 failed", summary)
+	}
+	if summary.Failed[0].Path != "fail1" {
+		t.Errorf("Run failures not sorted: %+v", summary.Failed)
+	}
+}
@@ -0,0 +1,168 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+const poInput = `# Translator comment
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: kaa_Latn\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#. extracted
+#: main.go:10
+#, c-format
+msgid "Hello, %s"
+msgstr "sálem, %s"
+
+msgctxt "menu"
+msgid "File"
+msgstr ""
+"fayl\n"
+"{{.Count}} zat"
+
+msgid "%d book"
+msgid_plural "%d books"
+msgstr[0] "%d kitap"
+msgstr[1] "%d kitap \"jaña\""
+
+msgid "untranslated"
+msgstr ""
+
+#~ msgid "old"
+#~ msgstr "eski"
+`
+
+func TestConvertPO(t *testing.T) {
+	want := `# Translator comment
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: kaa_Cyrl\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#. extracted
+#: main.go:10
+#, c-format
+msgid "Hello, %s"
+msgstr "SÁLEM, %s"
+
+msgctxt "menu"
+msgid "File"
+msgstr ""
+"FAYL\n"
+"{{.Count}} ZAT"
+
+msgid "%d book"
+msgid_plural "%d books"
+msgstr[0] "%d KITAP"
+msgstr[1] "%d KITAP \"JAÑA\""
+
+msgid "untranslated"
+msgstr ""
+
+#~ msgid "old"
+#~ msgstr "eski"
+`
+	got, err := ConvertPO(poInput, upper, Options{Lang: "Cyrl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ConvertPO =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConvertPOFuzzy(t *testing.T) {
+	got, err := ConvertPO(poInput, upper, Options{Fuzzy: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"#, c-format, fuzzy\nmsgid \"Hello", "#, fuzzy\nmsgctxt \"menu\"", "#, fuzzy\nmsgid \"%d book\"", "\nmsgid \"untranslated\""} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ConvertPO with Fuzzy: missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "#, fuzzy\nmsgid \"\"") || strings.Contains(got, "#, fuzzy\nmsgid \"untranslated\"") {
+		t.Errorf("ConvertPO with Fuzzy marked the header or an untranslated entry:\n%s", got)
+	}
+}
+
+func TestConvertPOErrors(t *testing.T) {
+	for _, input := range []string{"msgid \"a\"\nmsgstr \"b\"\nbogus\n", "\"orphan\"\n"} {
+		if _, err := ConvertPO(input, upper, Options{}); err == nil {
+			t.Errorf("ConvertPO(%q): no error", input)
+		}
+	}
+}
+
+func TestLocaleScript(t *testing.T) {
+	tests := map[string]string{
+		"kaa":          "kaa_Cyrl",
+		"kaa_Latn":     "kaa_Cyrl",
+		"kaa-Latn-UZ":  "kaa-Cyrl-UZ",
+		"kaa_UZ":       "kaa_Cyrl_UZ",
+		"kaa@latin":    "kaa@cyrillic",
+		"kaak":         "kaak",
+		"uz_Latn":      "uz_Latn",
+		"kaa_UZ@latin": "kaa_UZ@cyrillic",
+	}
+	for in, want := range tests {
+		if got := localeScript(in, "Cyrl"); got != want {
+			t.Errorf("localeScript(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestConvertXLIFF(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<xliff version="1.2"><file source-language="en" target-language="kaa-Latn">
+<body><trans-unit id="1"><source>Hello %s</source><target state="translated">sálem %s <ph id="1">&lt;b&gt;</ph></target><note>keep</note></trans-unit></body>
+</file></xliff>`
+	want := `<?xml version="1.0"?>
+<xliff version="1.2"><file source-language="en" target-language="kaa-Cyrl">
+<body><trans-unit id="1"><source>Hello %s</source><target state="needs-review-translation">SÁLEM %s <ph id="1">&lt;b&gt;</ph></target><note>keep</note></trans-unit></body>
+</file></xliff>`
+	if got := ConvertXLIFF(input, upper, Options{Lang: "Cyrl", Fuzzy: true}); got != want {
+		t.Errorf("ConvertXLIFF =\n%s\nwant\n%s", got, want)
+	}
+
+	input2 := `<xliff version="2.0" srcLang="en" trgLang="kaa-Cyrl"><file id="f"><unit id="u"><segment><source>Hi</source><target>сәлем</target></segment></unit></file></xliff>`
+	want2 := `<xliff version="2.0" srcLang="en" trgLang="kaa-Latn"><file id="f"><unit id="u"><segment state="initial" subState="kaalin:needs-review"><source>Hi</source><target>СӘЛЕМ</target></segment></unit></file></xliff>`
+	if got := ConvertXLIFF(input2, upper, Options{Lang: "Latn", Fuzzy: true}); got != want2 {
+		t.Errorf("ConvertXLIFF =\n%s\nwant\n%s", got, want2)
+	}
+}
+
+func TestConvertXLIFFLangAndState(t *testing.T) {
+	// Source language tags stay as they are, and targets that conversion
+	// leaves alone are not marked for review.
+	input := `<xliff version="1.2"><file source-language="kaa-Latn" target-language="kaa-Latn"><body>
+<trans-unit id="1"><source xml:lang="kaa-Latn">sálem</source><target xml:lang="kaa-Latn" state="translated">sálem</target></trans-unit>
+<trans-unit id="2"><source xml:lang="kaa-Latn">123</source><target xml:lang="kaa-Latn" state="translated">123</target></trans-unit>
+<!-- <target>kept</target> -->
+</body></file></xliff>`
+	want := `<xliff version="1.2"><file source-language="kaa-Latn" target-language="kaa-Cyrl"><body>
+<trans-unit id="1"><source xml:lang="kaa-Latn">sálem</source><target xml:lang="kaa-Cyrl" state="needs-review-translation">SÁLEM</target></trans-unit>
+<trans-unit id="2"><source xml:lang="kaa-Latn">123</source><target xml:lang="kaa-Cyrl" state="translated">123</target></trans-unit>
+<!-- <target>kept</target> -->
+</body></file></xliff>`
+	if got := ConvertXLIFF(input, upper, Options{Lang: "Cyrl", Fuzzy: true}); got != want {
+		t.Errorf("ConvertXLIFF =\n%s\nwant\n%s", got, want)
+	}
+
+	input2 := `<xliff version="2.0" srcLang="kaa-Latn" trgLang="kaa-Latn"><file id="f"><unit id="1"><segment state="translated"><source>sálem</source><target>sálem</target></segment></unit><unit id="2"><segment state="final"><source>123</source><target>123</target></segment></unit></file></xliff>`
+	want2 := `<xliff version="2.0" srcLang="kaa-Latn" trgLang="kaa-Cyrl"><file id="f"><unit id="1"><segment state="initial" subState="kaalin:needs-review"><source>sálem</source><target>SÁLEM</target></segment></unit><unit id="2"><segment state="final"><source>123</source><target>123</target></segment></unit></file></xliff>`
+	if got := ConvertXLIFF(input2, upper, Options{Lang: "Cyrl", Fuzzy: true}); got != want2 {
+		t.Errorf("ConvertXLIFF =\n%s\nwant\n%s", got, want2)
+	}
+}
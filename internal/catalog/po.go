@@ -0,0 +1,223 @@
+// Package catalog converts the translations in gettext PO and XLIFF
+// message catalogs, leaving source strings, comments, flags and
+// placeholders unchanged.
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/placeholder"
+)
+
+// Options controls catalog conversion.
+type Options struct {
+	// Lang is the script subtag of the converted catalog, "Latn" or
+	// "Cyrl", written into its language header or attributes. Empty
+	// leaves them unchanged.
+	Lang string
+
+	// Fuzzy marks converted entries as needing review: the fuzzy flag in
+	// PO files and the matching translation state in XLIFF.
+	Fuzzy bool
+}
+
+var (
+	poKeyword  = regexp.MustCompile(`^(msgctxt|msgid|msgid_plural|msgstr|msgstr\[\d+\])\s+"`)
+	poLanguage = regexp.MustCompile(`^(Language:[ \t]*)(.*?)((\\n)?)$`)
+)
+
+// poEntry is a run of non-blank lines: comments followed by keywords and
+// their strings.
+type poEntry struct {
+	lines   []string
+	header  bool
+	changed bool
+}
+
+// ConvertPO applies convert to the msgstr strings of a PO or POT file,
+// including plural forms. The header entry is not converted, but its
+// Language field is updated to opts.Lang.
+func ConvertPO(src string, convert func(string) string, opts Options) (string, error) {
+	var (
+		b     strings.Builder
+		entry []string
+		start int
+	)
+	lines := strings.SplitAfter(src, "\n")
+	flush := func() error {
+		if len(entry) == 0 {
+			return nil
+		}
+		out, err := convertEntry(entry, start, convert, opts)
+		if err != nil {
+			return err
+		}
+		b.WriteString(out)
+		entry = nil
+		return nil
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return "", err
+			}
+			b.WriteString(line)
+			continue
+		}
+		if entry == nil {
+			start = i + 1
+		}
+		entry = append(entry, line)
+	}
+	if err := flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// convertEntry converts one entry whose first line is line number start.
+func convertEntry(lines []string, start int, convert func(string) string, opts Options) (string, error) {
+	e := &poEntry{lines: lines, header: isHeader(lines)}
+
+	keyword := ""
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, `"`):
+			if keyword == "" {
+				return "", fmt.Errorf("line %d: string without a keyword", start+i)
+			}
+		default:
+			m := poKeyword.FindStringSubmatch(text)
+			if m == nil {
+				return "", fmt.Errorf("line %d: unexpected %q", start+i, text)
+			}
+			keyword = m[1]
+		}
+		if !strings.HasPrefix(keyword, "msgstr") {
+			continue
+		}
+
+		q1 := strings.IndexByte(text, '"')
+		q2 := strings.LastIndexByte(text, '"')
+		if q2 <= q1 {
+			return "", fmt.Errorf("line %d: unterminated string", start+i)
+		}
+		raw := text[q1+1 : q2]
+		var conv string
+		if e.header {
+			conv = headerLine(raw, opts.Lang)
+		} else {
+			conv = placeholder.ConvertEscaped(raw, convert)
+		}
+		if conv != raw {
+			e.changed = true
+			lines[i] = text[:q1+1] + conv + line[q2:]
+		}
+	}
+
+	if opts.Fuzzy && e.changed && !e.header {
+		e.markFuzzy()
+	}
+	return strings.Join(e.lines, ""), nil
+}
+
+// isHeader reports whether an entry is the header: an empty msgid
+// without a msgctxt.
+func isHeader(lines []string) bool {
+	keyword := ""
+	msgid := ""
+	for _, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, "msgctxt"):
+			return false
+		case strings.HasPrefix(text, `"`):
+		default:
+			keyword, _, _ = strings.Cut(text, " ")
+		}
+		if keyword == "msgid" {
+			q1 := strings.IndexByte(text, '"')
+			q2 := strings.LastIndexByte(text, '"')
+			if q2 > q1 {
+				msgid += text[q1+1 : q2]
+			}
+		}
+	}
+	return keyword != "" && msgid == ""
+}
+
+// headerLine updates the Language field in a line of the header.
+func headerLine(raw, lang string) string {
+	m := poLanguage.FindStringSubmatch(raw)
+	if lang == "" || m == nil {
+		return raw
+	}
+	return m[1] + localeScript(m[2], lang) + m[3]
+}
+
+// localeScript sets the script of a Karakalpak locale such as kaa,
+// kaa_Latn, kaa-Cyrl-UZ or kaa@latin.
+func localeScript(locale, script string) string {
+	lower := strings.ToLower(locale)
+	if !strings.HasPrefix(lower, "kaa") || (len(lower) > 3 && !strings.ContainsRune("_-@", rune(lower[3]))) {
+		return locale
+	}
+
+	base, modifier, hasModifier := strings.Cut(locale, "@")
+	if hasModifier && (strings.EqualFold(modifier, "latin") || strings.EqualFold(modifier, "cyrillic")) {
+		if script == "Latn" {
+			return base + "@latin"
+		}
+		return base + "@cyrillic"
+	}
+
+	parts := strings.FieldsFunc(base, func(r rune) bool { return r == '_' || r == '-' })
+	sep := "_"
+	if strings.Contains(base, "-") {
+		sep = "-"
+	}
+	for i, p := range parts[1:] {
+		if strings.EqualFold(p, "Latn") || strings.EqualFold(p, "Cyrl") {
+			parts[i+1] = script
+			return strings.Join(parts, sep) + strings.TrimPrefix(locale, base)
+		}
+	}
+	parts = append([]string{parts[0], script}, parts[1:]...)
+	return strings.Join(parts, sep) + strings.TrimPrefix(locale, base)
+}
+
+// markFuzzy adds the fuzzy flag to the entry's flags comment, adding one
+// before the previous-msgid comments and keywords if there is none.
+func (e *poEntry) markFuzzy() {
+	insert := len(e.lines)
+	for i, line := range e.lines {
+		if strings.HasPrefix(line, "#,") {
+			text := strings.TrimRight(line, "\r\n")
+			for _, flag := range strings.Split(text[2:], ",") {
+				if strings.TrimSpace(flag) == "fuzzy" {
+					return
+				}
+			}
+			e.lines[i] = text + ", fuzzy" + line[len(text):]
+			return
+		}
+		if !strings.HasPrefix(line, "#") || strings.HasPrefix(line, "#|") {
+			insert = i
+			break
+		}
+	}
+
+	eol := "\n"
+	if insert < len(e.lines) && strings.HasSuffix(e.lines[insert], "\r\n") {
+		eol = "\r\n"
+	}
+	e.lines = append(e.lines[:insert], append([]string{"#, fuzzy" + eol}, e.lines[insert:]...)...)
+}
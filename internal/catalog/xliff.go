@@ -0,0 +1,153 @@
+package catalog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/markup"
+	"github.com/dontbeidle/kaalin/internal/placeholder"
+)
+
+// xliffCode lists the XLIFF 1.2 inline elements that hold native code
+// rather than text.
+var xliffCode = []string{"bpt", "ept", "ph", "it"}
+
+// XLIFF 2.0 has no state for a translation awaiting review: initial is
+// the state before translation is done, and the subState says why.
+const (
+	xliff2ReviewState    = "initial"
+	xliff2ReviewSubState = "kaalin:needs-review"
+)
+
+var (
+	xliff2 = regexp.MustCompile(`<xliff\b[^>]*\bversion\s*=\s*["']2`)
+	// xliffTag matches comments and CDATA sections, which are copied as
+	// they are, and the start tags ConvertXLIFF looks at.
+	xliffTag = regexp.MustCompile(`<!--[\s\S]*?-->|<!\[CDATA\[[\s\S]*?\]\]>|<(xliff|file|segment|target)(?:\s[^>]*)?>`)
+)
+
+// ConvertXLIFF applies convert to the target elements of an XLIFF 1.2 or
+// 2.0 file. Sources, notes and inline code are copied unchanged. The
+// language tags of the targets and the file-level target-language (1.2)
+// or trgLang (2.0) are updated to opts.Lang; those of sources are not.
+func ConvertXLIFF(src string, convert func(string) string, opts Options) string {
+	v2 := xliff2.MatchString(src)
+
+	var b strings.Builder
+	b.Grow(len(src))
+	// The state of a 2.0 segment is only known once its target has been
+	// converted, so its start tag is held back until then.
+	var segment, held string
+	emit := func(s string) {
+		if segment != "" {
+			held += s
+		} else {
+			b.WriteString(s)
+		}
+	}
+	flush := func() {
+		b.WriteString(segment)
+		b.WriteString(held)
+		segment, held = "", ""
+	}
+
+	last := 0
+	for _, m := range xliffTag.FindAllStringSubmatchIndex(src, -1) {
+		start, end := m[0], m[1]
+		if start < last {
+			continue
+		}
+		emit(src[last:start])
+		last = end
+		tag := src[start:end]
+		if m[2] < 0 {
+			emit(tag)
+			continue
+		}
+
+		switch src[m[2]:m[3]] {
+		case "xliff":
+			flush()
+			b.WriteString(setLangAttr(tag, "trgLang", opts.Lang))
+		case "file":
+			flush()
+			b.WriteString(setLangAttr(tag, "target-language", opts.Lang))
+		case "segment":
+			flush()
+			segment = tag
+		case "target":
+			tag = setLangAttr(tag, "xml:lang", opts.Lang)
+			closing := strings.Index(src[end:], "</target>")
+			if strings.HasSuffix(tag, "/>") || closing < 0 {
+				emit(tag)
+				continue
+			}
+			text := src[end : end+closing]
+			converted := markup.Convert(text, func(s string) string {
+				return placeholder.Convert(s, convert)
+			}, markup.Options{XML: true, Skip: xliffCode})
+
+			if opts.Fuzzy && converted != text {
+				if v2 && segment != "" {
+					segment = setAttr(segment, "state", xliff2ReviewState)
+					segment = setAttr(segment, "subState", xliff2ReviewSubState)
+				} else if !v2 {
+					tag = setAttr(tag, "state", "needs-review-translation")
+				}
+			}
+			emit(tag)
+			emit(converted)
+			last = end + closing
+		}
+	}
+	flush()
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// setLangAttr rewrites the script subtag of a Karakalpak language tag in
+// the attribute name of a start tag. An empty script leaves it alone.
+func setLangAttr(tag, name, script string) string {
+	if script == "" {
+		return tag
+	}
+	value, ok := attr(tag, name)
+	if !ok {
+		return tag
+	}
+	if lang, ok := markup.LangTag(value, script); ok {
+		return setAttr(tag, name, lang)
+	}
+	return tag
+}
+
+// attrPatterns match the attributes ConvertXLIFF reads and sets, with
+// their quoted values.
+var attrPatterns = func() map[string]*regexp.Regexp {
+	m := map[string]*regexp.Regexp{}
+	for _, name := range []string{"trgLang", "target-language", "xml:lang", "state", "subState"} {
+		m[name] = regexp.MustCompile(`\s` + regexp.QuoteMeta(name) + `\s*=\s*("([^"]*)"|'([^']*)')`)
+	}
+	return m
+}()
+
+// attr returns the value of an attribute of a start tag.
+func attr(tag, name string) (string, bool) {
+	m := attrPatterns[name].FindStringSubmatch(tag)
+	if m == nil {
+		return "", false
+	}
+	return m[2] + m[3], true
+}
+
+// setAttr sets an attribute of a start tag, adding it if need be.
+func setAttr(tag, name, value string) string {
+	a := ` ` + name + `="` + value + `"`
+	if re := attrPatterns[name]; re.MatchString(tag) {
+		return re.ReplaceAllLiteralString(tag, a)
+	}
+	if strings.HasSuffix(tag, "/>") {
+		return strings.TrimSuffix(tag, "/>") + a + "/>"
+	}
+	return strings.TrimSuffix(tag, ">") + a + ">"
+}
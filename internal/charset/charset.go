@@ -0,0 +1,433 @@
+// Package charset decodes legacy Cyrillic encodings (Windows-1251,
+// KOI8-R and UTF-16) to UTF-8 and back, reporting invalid input bytes and
+// characters the output encoding cannot represent.
+package charset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// ErrUnknownEncoding is returned when an encoding name is not recognised.
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+type kind int
+
+const (
+	kindUTF8 kind = iota
+	kindCharmap
+	kindUTF16
+)
+
+// Encoding is a character encoding.
+type Encoding struct {
+	// Name is the canonical name, such as "utf-8" or "windows-1251".
+	Name string
+
+	kind      kind
+	charmap   *charmap.Charmap
+	bigEndian bool
+	bom       bool // UTF-16 output starts with a byte order mark
+}
+
+var (
+	UTF8        = Encoding{Name: "utf-8"}
+	Windows1251 = Encoding{Name: "windows-1251", kind: kindCharmap, charmap: charmap.Windows1251}
+	KOI8R       = Encoding{Name: "koi8-r", kind: kindCharmap, charmap: charmap.KOI8R}
+	UTF16       = Encoding{Name: "utf-16", kind: kindUTF16, bom: true}
+	UTF16LE     = Encoding{Name: "utf-16le", kind: kindUTF16}
+	UTF16BE     = Encoding{Name: "utf-16be", kind: kindUTF16, bigEndian: true}
+)
+
+// Lookup returns the encoding with the given name or alias.
+func Lookup(name string) (Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "utf-8", "utf8":
+		return UTF8, nil
+	case "windows-1251", "cp1251", "win1251", "1251":
+		return Windows1251, nil
+	case "koi8-r", "koi8r", "koi8":
+		return KOI8R, nil
+	case "utf-16", "utf16":
+		return UTF16, nil
+	case "utf-16le", "utf16le":
+		return UTF16LE, nil
+	case "utf-16be", "utf16be":
+		return UTF16BE, nil
+	}
+	return Encoding{}, fmt.Errorf("%q: %w", name, ErrUnknownEncoding)
+}
+
+// IsUTF8 reports whether e is UTF-8, which needs no transcoding.
+func (e Encoding) IsUTF8() bool {
+	return e.kind == kindUTF8
+}
+
+// IsUTF16 reports whether e is one of the UTF-16 encodings, whose text
+// contains NUL bytes.
+func (e Encoding) IsUTF16() bool {
+	return e.kind == kindUTF16
+}
+
+// declaration matches an XML encoding declaration or an HTML meta
+// charset.
+var declaration = regexp.MustCompile(`(?i)(?:encoding\s*=\s*["']|<meta[^>]+charset\s*=\s*["']?)([A-Za-z0-9_-]+)`)
+
+// utf16BE is big-endian UTF-16 read with a byte order mark, which is
+// written back with one.
+var utf16BE = Encoding{Name: "utf-16", kind: kindUTF16, bigEndian: true, bom: true}
+
+// Sniff guesses the encoding of text starting with head. A byte order
+// mark decides first, then the NUL bytes of UTF-16. Text that is valid
+// UTF-8 is UTF-8 unless it is plain ASCII with an XML or HTML charset
+// declaration. Anything else is read as a declared 8-bit encoding or as
+// whichever of Windows-1251 and KOI8-R gives more lower-case Cyrillic.
+func Sniff(head []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return UTF8
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return UTF16
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return utf16BE
+	}
+
+	var even, odd int
+	for i, b := range head {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	if n := len(head) / 4; n > 0 && odd > n {
+		return UTF16LE
+	} else if n > 0 && even > n {
+		return UTF16BE
+	}
+
+	declared, hasDeclared := UTF8, false
+	if m := declaration.FindSubmatch(head); m != nil {
+		if e, err := Lookup(string(m[1])); err == nil && e.kind != kindUTF16 {
+			declared, hasDeclared = e, true
+		}
+	}
+
+	if text := trimPartialRune(head); utf8.Valid(text) {
+		if hasDeclared && isASCII(text) {
+			return declared
+		}
+		return UTF8
+	}
+	if hasDeclared && declared.kind == kindCharmap {
+		return declared
+	}
+	if lowerCyrillic(head, charmap.KOI8R) > lowerCyrillic(head, charmap.Windows1251) {
+		return KOI8R
+	}
+	return Windows1251
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence cut off at the end
+// of head.
+func trimPartialRune(head []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(head); i++ {
+		if utf8.RuneStart(head[len(head)-i]) {
+			if !utf8.FullRune(head[len(head)-i:]) {
+				return head[:len(head)-i]
+			}
+			break
+		}
+	}
+	return head
+}
+
+func lowerCyrillic(head []byte, cm *charmap.Charmap) int {
+	n := 0
+	for _, b := range head {
+		if r := cm.DecodeByte(b); unicode.Is(unicode.Cyrillic, r) && unicode.IsLower(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// DecodeError reports input bytes that are not valid in their encoding.
+type DecodeError struct {
+	Encoding string
+	Offset   int64 // byte offset of the invalid input
+	Line     int
+	Bytes    []byte
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid %s input at byte %d (line %d): % #x", e.Encoding, e.Offset, e.Line, e.Bytes)
+}
+
+// NewDecoder returns a transformer from e to UTF-8 that fails with a
+// *DecodeError on invalid input. A leading UTF-16 byte order mark is
+// dropped; with UTF16 it also selects the byte order.
+func (e Encoding) NewDecoder() transform.Transformer {
+	return &decoder{enc: e, line: 1}
+}
+
+type decoder struct {
+	enc     Encoding
+	offset  int64
+	line    int
+	started bool
+}
+
+func (d *decoder) Reset() {
+	*d = decoder{enc: d.enc, line: 1}
+}
+
+func (d *decoder) fail(b []byte) error {
+	return &DecodeError{Encoding: d.enc.Name, Offset: d.offset, Line: d.line, Bytes: append([]byte(nil), b...)}
+}
+
+func (d *decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if d.enc.kind == kindUTF16 && !d.started {
+		if len(src) < 2 && !atEOF {
+			return 0, 0, transform.ErrShortSrc
+		}
+		d.started = true
+		if len(src) >= 2 {
+			switch {
+			case src[0] == 0xFF && src[1] == 0xFE:
+				d.enc.bigEndian = false
+				nSrc, d.offset = 2, 2
+			case src[0] == 0xFE && src[1] == 0xFF:
+				d.enc.bigEndian = true
+				nSrc, d.offset = 2, 2
+			}
+		}
+	}
+
+	for nSrc < len(src) {
+		var (
+			r    rune
+			size int
+		)
+		switch d.enc.kind {
+		case kindCharmap:
+			r, size = d.enc.charmap.DecodeByte(src[nSrc]), 1
+			if r == utf8.RuneError {
+				return nDst, nSrc, d.fail(src[nSrc : nSrc+1])
+			}
+		case kindUTF16:
+			if len(src)-nSrc < 2 {
+				if !atEOF {
+					return nDst, nSrc, transform.ErrShortSrc
+				}
+				return nDst, nSrc, d.fail(src[nSrc:])
+			}
+			r, size = rune(d.unit(src[nSrc:])), 2
+			if utf16.IsSurrogate(r) {
+				if len(src)-nSrc < 4 {
+					if !atEOF {
+						return nDst, nSrc, transform.ErrShortSrc
+					}
+					return nDst, nSrc, d.fail(src[nSrc:])
+				}
+				r, size = utf16.DecodeRune(r, rune(d.unit(src[nSrc+2:]))), 4
+				if r == utf8.RuneError {
+					return nDst, nSrc, d.fail(src[nSrc : nSrc+4])
+				}
+			}
+		default:
+			if !utf8.FullRune(src[nSrc:]) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			r, size = utf8.DecodeRune(src[nSrc:])
+			if r == utf8.RuneError && size <= 1 {
+				return nDst, nSrc, d.fail(src[nSrc : nSrc+max(size, 1)])
+			}
+		}
+
+		if nDst+utf8.RuneLen(r) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc += size
+		d.offset += int64(size)
+		if r == '\n' {
+			d.line++
+		}
+	}
+	return nDst, nSrc, nil
+}
+
+func (d *decoder) unit(b []byte) uint16 {
+	if d.enc.bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1])
+	}
+	return uint16(b[1])<<8 | uint16(b[0])
+}
+
+// Unrepresentable is a character that an encoding cannot represent.
+type Unrepresentable struct {
+	Rune   rune
+	Line   int
+	Column int
+}
+
+// EncodeError lists the characters an encoding could not represent.
+type EncodeError struct {
+	Encoding string
+	Chars    []Unrepresentable
+}
+
+// maxReported bounds the characters listed by EncodeError.Error.
+const maxReported = 10
+
+func (e *EncodeError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s cannot represent %d character", e.Encoding, len(e.Chars))
+	if len(e.Chars) != 1 {
+		b.WriteByte('s')
+	}
+	b.WriteString(":")
+	for i, c := range e.Chars {
+		if i == maxReported {
+			fmt.Fprintf(&b, " and %d more", len(e.Chars)-maxReported)
+			break
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %c (U+%04X) at %d:%d", c.Rune, c.Rune, c.Line, c.Column)
+	}
+	return b.String()
+}
+
+// Encoder is a transformer from UTF-8 to an encoding. Characters the
+// encoding cannot represent are written as '?' and recorded; Err reports
+// them once the input has been written. A byte order mark at the start of
+// the input is dropped.
+type Encoder struct {
+	enc     Encoding
+	chars   []Unrepresentable
+	line    int
+	column  int
+	started bool // the byte order mark has been written
+	read    bool // the first rune has been read
+}
+
+// NewEncoder returns an Encoder for e. UTF16 output starts with a byte
+// order mark.
+func (e Encoding) NewEncoder() *Encoder {
+	return &Encoder{enc: e, line: 1}
+}
+
+// Reset implements transform.Transformer.
+func (t *Encoder) Reset() {
+	*t = Encoder{enc: t.enc, line: 1}
+}
+
+// Err returns an *EncodeError listing the characters that could not be
+// represented, or nil.
+func (t *Encoder) Err() error {
+	if len(t.chars) == 0 {
+		return nil
+	}
+	return &EncodeError{Encoding: t.enc.Name, Chars: t.chars}
+}
+
+// Transform implements transform.Transformer.
+func (t *Encoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if !t.started {
+		if t.enc.kind == kindUTF16 && t.enc.bom {
+			if len(dst) < 2 {
+				return 0, 0, transform.ErrShortDst
+			}
+			nDst += t.put(dst, 0xFEFF)
+		}
+		t.started = true
+	}
+
+	for nSrc < len(src) {
+		if !utf8.FullRune(src[nSrc:]) && !atEOF {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		r, size := utf8.DecodeRune(src[nSrc:])
+		if !t.read {
+			t.read = true
+			if r == '\uFEFF' && !t.enc.IsUTF8() {
+				nSrc += size
+				continue
+			}
+		}
+		t.column++
+
+		switch t.enc.kind {
+		case kindCharmap:
+			if nDst >= len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			b, ok := t.enc.charmap.EncodeRune(r)
+			if !ok {
+				b = '?'
+				t.chars = append(t.chars, Unrepresentable{Rune: r, Line: t.line, Column: t.column})
+			}
+			dst[nDst] = b
+			nDst++
+		case kindUTF16:
+			r1, r2 := utf16.EncodeRune(r)
+			need := 2
+			if r1 != utf8.RuneError {
+				need = 4
+			}
+			if nDst+need > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			if need == 4 {
+				nDst += t.put(dst[nDst:], uint16(r1))
+				nDst += t.put(dst[nDst:], uint16(r2))
+			} else {
+				nDst += t.put(dst[nDst:], uint16(r))
+			}
+		default:
+			if nDst+size > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nDst += copy(dst[nDst:], src[nSrc:nSrc+size])
+		}
+
+		nSrc += size
+		if r == '\n' {
+			t.line++
+			t.column = 0
+		}
+	}
+	return nDst, nSrc, nil
+}
+
+func (t *Encoder) put(dst []byte, u uint16) int {
+	if t.enc.bigEndian {
+		dst[0], dst[1] = byte(u>>8), byte(u)
+	} else {
+		dst[0], dst[1] = byte(u), byte(u>>8)
+	}
+	return 2
+}
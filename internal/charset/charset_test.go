@@ -0,0 +1,157 @@
+package charset
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/transform"
+)
+
+func decode(t *testing.T, e Encoding, src []byte) (string, error) {
+	t.Helper()
+	out, _, err := transform.Bytes(e.NewDecoder(), src)
+	return string(out), err
+}
+
+func encode(t *testing.T, e Encoding, s string) ([]byte, error) {
+	t.Helper()
+	enc := e.NewEncoder()
+	out, _, err := transform.String(enc, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(out), enc.Err()
+}
+
+func TestLookup(t *testing.T) {
+	for name, want := range map[string]string{
+		"cp1251":       "windows-1251",
+		"Windows-1251": "windows-1251",
+		"KOI8-R":       "koi8-r",
+		"utf16":        "utf-16",
+		"utf-16be":     "utf-16be",
+		"UTF-8":        "utf-8",
+	} {
+		e, err := Lookup(name)
+		if err != nil || e.Name != want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", name, e.Name, err, want)
+		}
+	}
+	if _, err := Lookup("latin1"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("Lookup(latin1): err = %v, want ErrUnknownEncoding", err)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"utf-8", []byte("Сәлем, dúnya"), "utf-8"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFhello"), "utf-8"},
+		{"utf-16le bom", []byte("\xFF\xFEh\x00i\x00"), "utf-16"},
+		{"utf-16be bom", []byte("\xFE\xFF\x00h\x00i"), "utf-16"},
+		{"utf-16le", []byte("h\x00e\x00l\x00l\x00o\x00"), "utf-16le"},
+		{"utf-16be", []byte("\x00h\x00e\x00l\x00l\x00o"), "utf-16be"},
+		// "привет мир" in both 8-bit encodings
+		{"windows-1251", []byte("\xEF\xF0\xE8\xE2\xE5\xF2 \xEC\xE8\xF0"), "windows-1251"},
+		{"koi8-r", []byte("\xD0\xD2\xC9\xD7\xC5\xD4 \xCD\xC9\xD2"), "koi8-r"},
+		{"declared ascii", []byte(`<?xml version="1.0" encoding="windows-1251"?><a/>`), "windows-1251"},
+		{"meta charset", []byte(`<meta charset="koi8-r"><p>`), "koi8-r"},
+		{"stale declaration", []byte(`<?xml version="1.0" encoding="windows-1251"?><a>Сәлем</a>`), "utf-8"},
+		{"partial rune", []byte("Сә")[:3], "utf-8"},
+	}
+	for _, tt := range tests {
+		if got := Sniff(tt.head).Name; got != tt.want {
+			t.Errorf("%s: Sniff = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		enc  Encoding
+		src  string
+		want string
+	}{
+		{Windows1251, "\xD1\xE0\xEB\xE5\xEC \xA1", "Салем Ў"},
+		{KOI8R, "\xF3\xC1\xCC\xC5\xCD", "Салем"},
+		{UTF16, "\xFF\xFE\x21\x04\x30\x04", "Са"},
+		{UTF16, "\xFE\xFF\x04\x21\x04\x30", "Са"},
+		{UTF16LE, "\x3D\xD8\x00\xDE", "\U0001F600"},
+		{UTF16BE, "\x04\x21", "С"},
+	}
+	for _, tt := range tests {
+		got, err := decode(t, tt.enc, []byte(tt.src))
+		if err != nil || got != tt.want {
+			t.Errorf("%s: decode(%q) = %q, %v; want %q", tt.enc.Name, tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		enc    Encoding
+		src    string
+		offset int64
+		line   int
+	}{
+		{Windows1251, "ab\ncd\x98", 5, 2},
+		{UTF8, "ok\n\n\xFF", 4, 3},
+		{UTF16LE, "a\x00\x00\xDC", 2, 1},
+		{UTF16LE, "a\x00b", 2, 1},
+	}
+	for _, tt := range tests {
+		_, err := decode(t, tt.enc, []byte(tt.src))
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("%s: decode(%q): err = %v, want *DecodeError", tt.enc.Name, tt.src, err)
+			continue
+		}
+		if de.Offset != tt.offset || de.Line != tt.line {
+			t.Errorf("%s: decode(%q): offset %d line %d, want %d %d", tt.enc.Name, tt.src, de.Offset, de.Line, tt.offset, tt.line)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	out, err := encode(t, Windows1251, "\uFEFFСалем Ўзбек")
+	if err != nil || string(out) != "\xD1\xE0\xEB\xE5\xEC \xA1\xE7\xE1\xE5\xEA" {
+		t.Errorf("windows-1251: %q, %v", out, err)
+	}
+
+	out, err = encode(t, UTF16, "Са")
+	if err != nil || string(out) != "\xFF\xFE\x21\x04\x30\x04" {
+		t.Errorf("utf-16: %q, %v", out, err)
+	}
+
+	out, err = encode(t, UTF16BE, "\U0001F600")
+	if err != nil || string(out) != "\xD8\x3D\xDE\x00" {
+		t.Errorf("utf-16be: %q, %v", out, err)
+	}
+}
+
+func TestEncodeUnrepresentable(t *testing.T) {
+	out, err := encode(t, Windows1251, "Қала\nsu Ғárip Ҳ")
+	if string(out) != "?\xE0\xEB\xE0\nsu ??rip ?" {
+		t.Errorf("out = %q", out)
+	}
+	var ee *EncodeError
+	if !errors.As(err, &ee) {
+		t.Fatalf("err = %v, want *EncodeError", err)
+	}
+	want := []Unrepresentable{{'Қ', 1, 1}, {'Ғ', 2, 4}, {'á', 2, 5}, {'Ҳ', 2, 10}}
+	if !reflect.DeepEqual(ee.Chars, want) {
+		t.Errorf("chars = %v, want %v", ee.Chars, want)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "4 characters") || !strings.Contains(msg, "Қ (U+049A) at 1:1") {
+		t.Errorf("message = %q", msg)
+	}
+
+	if _, err := encode(t, KOI8R, "Ә Ө Ү Ң Ў"); err == nil || len(err.(*EncodeError).Chars) != 5 {
+		t.Errorf("koi8-r: err = %v, want 5 characters", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Package config loads the optional kaalin configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPath names an environment variable that overrides the config file location.
+const EnvPath = "KAALIN_CONFIG"
+
+// BuiltinDict stands for the built-in exception list where a dictionary
+// file is expected.
+const BuiltinDict = "builtin"
+
+// Config holds settings read from the config file.
+type Config struct {
+	// Dict is a user exception dictionary, relative to the config file,
+	// or BuiltinDict.
+	Dict string `yaml:"dict"`
+}
+
+// Path returns the config file location: $KAALIN_CONFIG, or
+// kaalin/config.yaml under the user config directory.
+func Path() string {
+	if p := os.Getenv(EnvPath); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "kaalin", "config.yaml")
+}
+
+// Load reads the config file. A missing file yields an empty Config.
+func Load() (Config, error) {
+	return LoadFile(Path())
+}
+
+// LoadFile reads the config file at path. A missing file yields an empty Config.
+func LoadFile(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %s", path, err)
+	}
+	if cfg.Dict != "" && cfg.Dict != BuiltinDict && !filepath.IsAbs(cfg.Dict) {
+		cfg.Dict = filepath.Join(filepath.Dir(path), cfg.Dict)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("dict: words.tsv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "words.tsv"); cfg.Dict != want {
+		t.Errorf("Dict = %q, want %q", cfg.Dict, want)
+	}
+}
+
+func TestLoadFileBuiltinDict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("dict: builtin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dict != BuiltinDict {
+		t.Errorf("Dict = %q, want %q", cfg.Dict, BuiltinDict)
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "none.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile of missing file returned error: %v", err)
+	}
+	if cfg != (Config{}) {
+		t.Errorf("LoadFile of missing file = %+v, want empty", cfg)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("dict: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile of invalid YAML should return error")
+	}
+}
+
+func TestPathEnv(t *testing.T) {
+	t.Setenv(EnvPath, "/tmp/kaalin.yaml")
+	if got := Path(); got != "/tmp/kaalin.yaml" {
+		t.Errorf("Path() = %q, want /tmp/kaalin.yaml", got)
+	}
+}
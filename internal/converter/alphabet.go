@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The 1994/2009 Karakalpak Latin alphabet writes the letters that the
+// 2016 alphabet marks with an acute accent as a base letter followed by
+// an apostrophe: a' o' u' g' n' i' for á ó ú ǵ ń ı.
+
+var oldLatinToLatin = map[rune]string{
+	'a': "á", 'A': "Á",
+	'o': "ó", 'O': "Ó",
+	'u': "ú", 'U': "Ú",
+	'g': "ǵ", 'G': "Ǵ",
+	'n': "ń", 'N': "Ń",
+	'i': "ı", 'I': "Í",
+}
+
+var latinToOldLatin = map[rune]string{
+	'á': "a'", 'Á': "A'",
+	'ó': "o'", 'Ó': "O'",
+	'ú': "u'", 'Ú': "U'",
+	'ǵ': "g'", 'Ǵ': "G'",
+	'ń': "n'", 'Ń': "N'",
+	'ı': "i'", 'Í': "I'",
+}
+
+// isApostrophe reports whether r is one of the apostrophe variants used
+// as a diacritic in the old Latin alphabet.
+func isApostrophe(r rune) bool {
+	switch r {
+	case '\'', '’', 'ʼ', 'ʻ':
+		return true
+	}
+	return false
+}
+
+// OldLatinToLatin rewrites text in the 1994/2009 apostrophe alphabet into
+// the 2016 acute-accent alphabet. An apostrophe is kept as punctuation
+// when it separates a letter from a following y (dun'ya), and when it
+// opens or closes a quotation on the same line ('bala').
+func OldLatinToLatin(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	b.Grow(len(text))
+
+	quoteOpen := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' {
+			quoteOpen = false
+		}
+
+		if i+1 < len(runes) && isApostrophe(runes[i+1]) {
+			if repl, ok := oldLatinToLatin[r]; ok && isDiacriticApostrophe(runes, i+1, quoteOpen) {
+				b.WriteString(repl)
+				i++
+				continue
+			}
+		}
+
+		if isApostrophe(r) {
+			prevLetter := i > 0 && unicode.IsLetter(runes[i-1])
+			nextLetter := i+1 < len(runes) && unicode.IsLetter(runes[i+1])
+			switch {
+			case !prevLetter && nextLetter:
+				quoteOpen = true
+			case prevLetter && !nextLetter:
+				quoteOpen = false
+			}
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// isDiacriticApostrophe decides whether the apostrophe at runes[i], which
+// follows a letter that can take it, marks that letter.
+func isDiacriticApostrophe(runes []rune, i int, quoteOpen bool) bool {
+	if i+1 >= len(runes) {
+		return !quoteOpen
+	}
+	next := runes[i+1]
+	if next == 'y' || next == 'Y' {
+		return false
+	}
+	if !unicode.IsLetter(next) && quoteOpen {
+		return false
+	}
+	return true
+}
+
+// LatinToOldLatin rewrites text in the 2016 acute-accent alphabet into the
+// 1994/2009 apostrophe alphabet, using ASCII apostrophes.
+func LatinToOldLatin(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for _, r := range text {
+		if repl, ok := latinToOldLatin[r]; ok {
+			b.WriteString(repl)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
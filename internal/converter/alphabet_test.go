@@ -0,0 +1,69 @@
+package converter
+
+import "testing"
+
+func TestOldLatinToLatin(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"all letters", "a' o' u' g' n' i'", "á ó ú ǵ ń ı"},
+		{"uppercase", "A'O'U'G'N'I'", "ÁÓÚǴŃÍ"},
+		{"word", "Sa'lem", "Sálem"},
+		{"genitive", "menin' kitabi'm", "meniń kitabım"},
+		{"right single quote", "Sa’lem", "Sálem"},
+		{"modifier apostrophe", "Saʼlem", "Sálem"},
+		{"turned comma", "gʻarri", "ǵarri"},
+		{"separator before y", "Sa'lem, dun'ya!", "Sálem, dun'ya!"},
+		{"quoted word", "'bala' dedi", "'bala' dedi"},
+		{"quoted phrase", "ol 'jaqsi' bala'", "ol 'jaqsi' balá"},
+		{"quote closes at newline", "'qala\nbala'", "'qala\nbalá"},
+		{"other letters untouched", "l'avenir", "l'avenir"},
+		{"no apostrophes", "Assalawma áleykum", "Assalawma áleykum"},
+		{"cyrillic untouched", "Сәлем", "Сәлем"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OldLatinToLatin(tt.input); got != tt.want {
+				t.Errorf("OldLatinToLatin(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatinToOldLatin(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Sálem", "Sa'lem"},
+		{"ÁÓÚǴŃÍ", "A'O'U'G'N'I'"},
+		{"qırıq", "qi'ri'q"},
+		{"dun'ya", "dun'ya"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := LatinToOldLatin(tt.input); got != tt.want {
+				t.Errorf("LatinToOldLatin(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOldLatinRoundTrip(t *testing.T) {
+	inputs := []string{
+		"Assalawma áleykum",
+		"Sálem, dun'ya!",
+		"meniń kitabım qırıq ǵarri",
+		"ÁLEM ŃÍ",
+	}
+
+	for _, input := range inputs {
+		if got := OldLatinToLatin(LatinToOldLatin(input)); got != input {
+			t.Errorf("OldLatinToLatin(LatinToOldLatin(%q)) = %q", input, got)
+		}
+	}
+}
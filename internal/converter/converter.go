@@ -14,51 +14,51 @@ func Latin2Cyrillic(text string) string {
 	i := 0
 
 	for i < len(runes) {
-		// Try 2-character combinations first (uppercase)
+		// Try 2-character combinations first
 		if i+1 < len(runes) {
-			pair := string(runes[i : i+2])
-
-			found := false
-			for _, m := range latToCyrMultiUpper {
-				if pair == m.Latin {
-					b.WriteString(m.Cyrillic)
-					i += 2
-					found = true
-					break
-				}
-			}
-			if found {
-				continue
-			}
-
-			for _, m := range latToCyrMultiLower {
-				if pair == m.Latin {
-					b.WriteString(m.Cyrillic)
-					i += 2
-					found = true
-					break
-				}
-			}
-			if found {
+			if repl, ok := latinPair(runes[i], runes[i+1]); ok {
+				b.WriteString(repl)
+				i += 2
 				continue
 			}
 		}
 
 		// Single character mapping
-		r := runes[i]
-		if repl, ok := latToCyrUpper[r]; ok {
-			b.WriteString(repl)
-		} else if repl, ok := latToCyrLower[r]; ok {
-			b.WriteString(repl)
-		} else {
-			b.WriteRune(r)
-		}
+		b.WriteString(latinRune(runes[i]))
 		i++
 	}
 
 	return b.String()
 }
 
+// latinPair returns the Cyrillic letter for a Latin digraph such as "sh" or "Ya".
+func latinPair(a, b rune) (string, bool) {
+	if !isDigraphStart(a) {
+		return "", false
+	}
+	pair := string([]rune{a, b})
+	for _, m := range latToCyrMultiUpper {
+		if pair == m.Latin {
+			return m.Cyrillic, true
+		}
+	}
+	for _, m := range latToCyrMultiLower {
+		if pair == m.Latin {
+			return m.Cyrillic, true
+		}
+	}
+	return "", false
+}
+
+// isDigraphStart reports whether r can begin a Latin digraph.
+func isDigraphStart(r rune) bool {
+	switch r {
+	case 's', 'S', 'c', 'C', 'y', 'Y':
+		return true
+	}
+	return false
+}
+
 // Cyrillic2Latin converts Karakalpak Cyrillic text to Latin.
 func Cyrillic2Latin(text string) string {
 	// Apply special rules before conversion (only when NOT at word start)
@@ -68,13 +68,7 @@ func Cyrillic2Latin(text string) string {
 	b.Grow(len(text))
 
 	for _, r := range text {
-		if repl, ok := cyrToLatUpper[r]; ok {
-			b.WriteString(repl)
-		} else if repl, ok := cyrToLatLower[r]; ok {
-			b.WriteString(repl)
-		} else {
-			b.WriteRune(r)
-		}
+		b.WriteString(cyrillicRune(r))
 	}
 
 	return b.String()
@@ -90,18 +84,8 @@ func applySpecialRules(text string) string {
 	i := 0
 	for i < len(runes) {
 		if i > 0 && i+1 < len(runes) && !isWordBoundary(runes[i-1]) {
-			pair := string(runes[i : i+2])
-			switch pair {
-			case "ьи":
-				b.WriteString("yi")
-				i += 2
-				continue
-			case "ьо":
-				b.WriteString("yo")
-				i += 2
-				continue
-			case "ъе":
-				b.WriteString("ye")
+			if repl, ok := specialPair(runes[i], runes[i+1]); ok {
+				b.WriteString(repl)
 				i += 2
 				continue
 			}
@@ -115,6 +99,19 @@ func applySpecialRules(text string) string {
 	return b.String()
 }
 
+// specialPair returns the Latin spelling of ьи, ьо and ъе.
+func specialPair(a, b rune) (string, bool) {
+	switch string([]rune{a, b}) {
+	case "ьи":
+		return "yi", true
+	case "ьо":
+		return "yo", true
+	case "ъе":
+		return "ye", true
+	}
+	return "", false
+}
+
 func isWordBoundary(r rune) bool {
 	return r == ' ' || r == '\t' || r == '\n' || r == '\r' ||
 		!utf8.ValidRune(r)
@@ -1,6 +1,9 @@
 package converter
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestLatin2Cyrillic(t *testing.T) {
 	tests := []struct {
@@ -159,3 +162,62 @@ func TestDetectScript(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectScriptReader(t *testing.T) {
+	inputs := []string{"Сәлем", "Sálem", "12345", "", "Сa hello world"}
+
+	for _, input := range inputs {
+		got, err := DetectScriptReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("DetectScriptReader(%q) returned error: %v", input, err)
+		}
+		if want := DetectScript(input); got != want {
+			t.Errorf("DetectScriptReader(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestScriptDetector(t *testing.T) {
+	pieces := []string{"Sálem", "Сәлем", "дүнья", "123"}
+
+	var d ScriptDetector
+	for _, p := range pieces {
+		d.Add(p)
+	}
+	if want := DetectScript(strings.Join(pieces, "")); d.Script() != want {
+		t.Errorf("ScriptDetector.Script() = %q, want %q", d.Script(), want)
+	}
+}
+
+func TestDetectSegments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Segment
+	}{
+		{"empty", "", nil},
+		{"no letters", "123 !", []Segment{{0, 5, "unknown", 0}}},
+		{"single script", "Sálem, dúnya!", []Segment{{0, 15, "latin", 1}}},
+		{"mixed", "Сәлем hello 42 дүнья", []Segment{
+			{0, 11, "cyrillic", 1},
+			{11, 20, "latin", 1},
+			{20, 30, "cyrillic", 1},
+		}},
+		{"leading punctuation", "« Sálem", []Segment{{0, 9, "latin", 1}}},
+		{"mixed word", "Сalem", []Segment{{0, 6, "latin", 0.8}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectSegments(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DetectSegments(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("DetectSegments(%q)[%d] = %+v, want %+v", tt.input, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,23 +1,166 @@
 package converter
 
-import "unicode"
+import (
+	"bufio"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
 
 // DetectScript analyzes text and returns "cyrillic", "latin", or "unknown".
 func DetectScript(text string) string {
-	var cyrCount, latCount int
+	var c scriptCounter
+	for _, r := range text {
+		c.add(r)
+	}
+	return c.result()
+}
+
+// DetectScriptReader is like DetectScript but reads the text from r
+// without holding it in memory.
+func DetectScriptReader(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	var c scriptCounter
+	for {
+		ch, _, err := br.ReadRune()
+		if err == io.EOF {
+			return c.result(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		c.add(ch)
+	}
+}
+
+// ScriptDetector is DetectScript for text that arrives in pieces: the
+// result is that of DetectScript on the pieces joined. The zero value is
+// ready to use.
+type ScriptDetector struct {
+	c scriptCounter
+}
 
+// Add counts the letters of text.
+func (d *ScriptDetector) Add(text string) {
 	for _, r := range text {
-		if isCyrillic(r) {
-			cyrCount++
-		} else if isLatin(r) {
-			latCount++
+		d.c.add(r)
+	}
+}
+
+// Script returns "cyrillic", "latin", or "unknown" for the text added so
+// far.
+func (d *ScriptDetector) Script() string {
+	return d.c.result()
+}
+
+// Segment is a run of text in one script, as found by DetectSegments.
+// Start and End are byte offsets into the text. Confidence is the share
+// of the segment's letters that belong to Script, from 0 to 1.
+type Segment struct {
+	Start, End int
+	Script     string
+	Confidence float64
+}
+
+// DetectSegments splits text into runs of words written in the same
+// script, so that mixed-script documents can be handled piece by piece.
+// Each word is classified by the majority of its letters. The segments
+// cover the whole text: spaces, digits and punctuation belong to the
+// segment before them, or to the first one at the start of the text.
+func DetectSegments(text string) []Segment {
+	var (
+		segs   []Segment
+		counts []scriptCounter
+	)
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isWordRune(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		var c scriptCounter
+		for i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !isWordRune(r) {
+				break
+			}
+			c.add(r)
+			i += size
 		}
+
+		script := c.result()
+		if n := len(segs); n > 0 && segs[n-1].Script == script {
+			segs[n-1].End = i
+			counts[n-1].merge(c)
+			continue
+		}
+		if n := len(segs); n > 0 {
+			segs[n-1].End = start
+		} else {
+			start = 0
+		}
+		segs = append(segs, Segment{Start: start, End: i, Script: script})
+		counts = append(counts, c)
+	}
+
+	if len(segs) == 0 {
+		if text == "" {
+			return nil
+		}
+		return []Segment{{Start: 0, End: len(text), Script: "unknown"}}
+	}
+
+	segs[len(segs)-1].End = len(text)
+	for i := range segs {
+		segs[i].Confidence = counts[i].confidence(segs[i].Script)
+	}
+	return segs
+}
+
+type scriptCounter struct {
+	cyr, lat, other int
+}
+
+func (c *scriptCounter) add(r rune) {
+	switch {
+	case isCyrillic(r):
+		c.cyr++
+	case isLatin(r):
+		c.lat++
+	case unicode.IsLetter(r):
+		c.other++
+	}
+}
+
+func (c *scriptCounter) merge(o scriptCounter) {
+	c.cyr += o.cyr
+	c.lat += o.lat
+	c.other += o.other
+}
+
+// confidence returns the share of letters written in script.
+func (c *scriptCounter) confidence(script string) float64 {
+	total := c.cyr + c.lat + c.other
+	if total == 0 {
+		return 0
 	}
+	switch script {
+	case "cyrillic":
+		return float64(c.cyr) / float64(total)
+	case "latin":
+		return float64(c.lat) / float64(total)
+	}
+	return float64(c.other) / float64(total)
+}
 
-	if cyrCount == 0 && latCount == 0 {
+func (c *scriptCounter) result() string {
+	if c.cyr == 0 && c.lat == 0 {
 		return "unknown"
 	}
-	if cyrCount >= latCount {
+	if c.cyr >= c.lat {
 		return "cyrillic"
 	}
 	return "latin"
@@ -0,0 +1,165 @@
+package converter
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dontbeidle/kaalin/internal/strutil"
+)
+
+//go:embed dict_default.tsv
+var defaultDictTSV string
+
+// Dictionary holds whole-word overrides for loanwords and proper nouns
+// that the letter tables get wrong. Each entry pairs a Latin and a
+// Cyrillic spelling and applies in both directions. Lookups ignore case,
+// and the case pattern of the input word (lower, Title or UPPER) is
+// carried over to the replacement.
+type Dictionary struct {
+	toCyr map[string]string
+	toLat map[string]string
+}
+
+// NewDictionary returns an empty dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		toCyr: make(map[string]string),
+		toLat: make(map[string]string),
+	}
+}
+
+// DefaultDictionary returns a new copy of the built-in exception list.
+func DefaultDictionary() *Dictionary {
+	d, err := ParseDictionaryTSV(strings.NewReader(defaultDictTSV))
+	if err != nil {
+		panic("converter: invalid built-in dictionary: " + err.Error())
+	}
+	return d
+}
+
+// ParseDictionaryTSV reads entries of the form "latin<TAB>cyrillic", one per
+// line. Blank lines and lines starting with # are ignored.
+func ParseDictionaryTSV(r io.Reader) (*Dictionary, error) {
+	d := NewDictionary()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) != 2 || strings.TrimSpace(cols[0]) == "" || strings.TrimSpace(cols[1]) == "" {
+			return nil, fmt.Errorf("line %d: expected latin and cyrillic separated by a tab", line)
+		}
+		d.Add(strings.TrimSpace(cols[0]), strings.TrimSpace(cols[1]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Add registers a Latin/Cyrillic pair, replacing earlier entries for
+// either spelling.
+func (d *Dictionary) Add(latin, cyrillic string) {
+	d.toCyr[strutil.Lower(latin)] = cyrillic
+	d.toLat[strutil.Lower(cyrillic)] = latin
+}
+
+// Merge adds all entries of other, which take precedence.
+func (d *Dictionary) Merge(other *Dictionary) {
+	for k, v := range other.toCyr {
+		d.toCyr[k] = v
+	}
+	for k, v := range other.toLat {
+		d.toLat[k] = v
+	}
+}
+
+// Len returns the number of entries.
+func (d *Dictionary) Len() int {
+	return len(d.toCyr)
+}
+
+// Latin2Cyrillic is like the package-level Latin2Cyrillic but replaces
+// dictionary words first.
+func (d *Dictionary) Latin2Cyrillic(text string) string {
+	return d.convert(text, d.toCyr, Latin2Cyrillic)
+}
+
+// Cyrillic2Latin is like the package-level Cyrillic2Latin but replaces
+// dictionary words first.
+func (d *Dictionary) Cyrillic2Latin(text string) string {
+	return d.convert(text, d.toLat, Cyrillic2Latin)
+}
+
+// convert replaces dictionary words and converts the text between them
+// with fallback. The spans handed to fallback start and end at non-letter
+// runes, so digraphs and the ьи/ьо/ъе rules behave as on the whole text.
+func (d *Dictionary) convert(text string, table map[string]string, fallback func(string) string) string {
+	if len(table) == 0 {
+		return fallback(text)
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+
+	last := 0
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isWordRune(r) {
+			i += size
+			continue
+		}
+
+		j := i + size
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !isWordRune(r) {
+				break
+			}
+			j += size
+		}
+
+		word := text[i:j]
+		if repl, ok := table[strutil.Lower(word)]; ok {
+			b.WriteString(fallback(text[last:i]))
+			b.WriteString(matchCase(word, repl))
+			last = j
+		}
+		i = j
+	}
+	b.WriteString(fallback(text[last:]))
+
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r)
+}
+
+// matchCase applies the case pattern of word to repl: all lower, all
+// upper or title case. Other patterns keep repl as written.
+func matchCase(word, repl string) string {
+	switch {
+	case word == strutil.Lower(word):
+		return strutil.Lower(repl)
+	case word == strutil.Upper(word) && utf8.RuneCountInString(word) > 1:
+		return strutil.Upper(repl)
+	}
+
+	first, size := utf8.DecodeRuneInString(word)
+	if unicode.IsUpper(first) && word[size:] == strutil.Lower(word[size:]) {
+		r, n := utf8.DecodeRuneInString(repl)
+		return strutil.Upper(string(r)) + strutil.Lower(repl[n:])
+	}
+	return repl
+}
@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/transform"
+)
+
+func TestDictionaryLatin2Cyrillic(t *testing.T) {
+	d := DefaultDictionary()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Moskva", "Москва"},
+		{"MOSKVA", "МОСКВА"},
+		{"moskva", "москва"},
+		{"Moskva qalası", "Москва қаласы"},
+		{"1-iyun, 25-dekabr.", "1-июнь, 25-декабрь."},
+		{"Jańa kompyuter", "Жаңа компьютер"},
+		{"Sálem, dun'ya!", "Сәлем, дун'я!"},
+		{"kompyuterler", "компютерлер"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := d.Latin2Cyrillic(tt.input); got != tt.want {
+				t.Errorf("Latin2Cyrillic(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDictionaryCyrillic2Latin(t *testing.T) {
+	d := NewDictionary()
+	d.Add("Sirk", "цирк")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"цирк", "sirk"},
+		{"Цирк", "Sirk"},
+		{"ЦИРК", "SIRK"},
+		{"цирк обьор", "sirk obyor"},
+		{"кальций", "kalciy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := d.Cyrillic2Latin(tt.input); got != tt.want {
+				t.Errorf("Cyrillic2Latin(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDictionaryMatchesPlainConversion(t *testing.T) {
+	empty := NewDictionary()
+	for _, input := range transformSamples {
+		if got, want := empty.Latin2Cyrillic(input), Latin2Cyrillic(input); got != want {
+			t.Errorf("empty Latin2Cyrillic(%q) = %q, want %q", input, got, want)
+		}
+		if got, want := empty.Cyrillic2Latin(input), Cyrillic2Latin(input); got != want {
+			t.Errorf("empty Cyrillic2Latin(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseDictionaryTSV(t *testing.T) {
+	d, err := ParseDictionaryTSV(strings.NewReader("# comment\n\nMoskva\tМосква\nkompyuter\tкомпьютер\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", d.Len())
+	}
+
+	if _, err := ParseDictionaryTSV(strings.NewReader("Moskva Москва\n")); err == nil {
+		t.Error("ParseDictionaryTSV without a tab should return error")
+	}
+}
+
+func TestDictionaryMerge(t *testing.T) {
+	d := DefaultDictionary()
+	user := NewDictionary()
+	user.Add("Moskva", "МОСКВА-СИТИ")
+	d.Merge(user)
+
+	if got := d.Latin2Cyrillic("Moskva"); got != "Москва-сити" {
+		t.Errorf("merged Latin2Cyrillic(Moskva) = %q, want Москва-сити", got)
+	}
+}
+
+func TestChunkTransformer(t *testing.T) {
+	d := DefaultDictionary()
+	inputs := append([]string{
+		"Moskva moskva\nMOSKVA",
+		strings.Repeat("Moskva, 1-iyun sentyabr ", 300),
+		strings.Repeat("x", 5000) + " Moskva",
+	}, transformSamples...)
+
+	for _, input := range inputs {
+		want := d.Latin2Cyrillic(input)
+		if got := writeBytewise(t, NewChunkTransformer(d.Latin2Cyrillic), input); got != want {
+			t.Errorf("ChunkTransformer bytewise(%.20q) differs from whole conversion", input)
+		}
+		got, _, err := transform.String(NewChunkTransformer(d.Latin2Cyrillic), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("ChunkTransformer(%.20q) differs from whole conversion", input)
+		}
+
+		want = Cyrillic2Latin(input)
+		if got := writeBytewise(t, NewChunkTransformer(Cyrillic2Latin), input); got != want {
+			t.Errorf("ChunkTransformer(Cyrillic2Latin) bytewise(%.20q) differs from whole conversion", input)
+		}
+	}
+}
+
+func TestChunkTransformerLongRun(t *testing.T) {
+	d := DefaultDictionary()
+	// Runs without whitespace far longer than any read buffer, full of
+	// digraphs and ending in a dictionary word.
+	inputs := []string{
+		strings.Repeat("sh", 5000) + "-Moskva",
+		strings.Repeat("объект", 3000) + "\n" + strings.Repeat("ьи", 3000),
+	}
+
+	for _, input := range inputs {
+		want := d.Latin2Cyrillic(input)
+		if got := writeBytewise(t, NewChunkTransformer(d.Latin2Cyrillic), input); got != want {
+			t.Errorf("ChunkTransformer bytewise(%.20q) differs from whole conversion", input)
+		}
+		got, err := io.ReadAll(transform.NewReader(strings.NewReader(input), NewChunkTransformer(d.Latin2Cyrillic)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("ChunkTransformer reader(%.20q) differs from whole conversion", input)
+		}
+
+		want = Cyrillic2Latin(input)
+		if got := writeBytewise(t, NewLineChunkTransformer(Cyrillic2Latin), input); got != want {
+			t.Errorf("LineChunkTransformer(Cyrillic2Latin) bytewise(%.20q) differs from whole conversion", input)
+		}
+	}
+}
+
+func TestChunkTransformerMaxCarry(t *testing.T) {
+	d := DefaultDictionary()
+	inputs := []string{
+		// Cut after the commas, so every digraph converts as a whole.
+		strings.Repeat("shahar,", 40000),
+		// No character outside words: cut between two letters.
+		strings.Repeat("á", 100000),
+	}
+
+	for _, input := range inputs {
+		tr := NewChunkTransformer(d.Latin2Cyrillic)
+		dst := make([]byte, 1<<20)
+		var out []byte
+		for src := []byte(input); len(src) > 0; {
+			n := min(len(src), 4096)
+			nDst, nSrc, err := tr.Transform(dst, src[:n], false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, dst[:nDst]...)
+			src = src[nSrc:]
+			if len(tr.carry) > chunkMaxCarry {
+				t.Fatalf("carry holds %d bytes, more than %d", len(tr.carry), chunkMaxCarry)
+			}
+		}
+		nDst, _, err := tr.Transform(dst, nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, dst[:nDst]...)
+
+		if string(out) != d.Latin2Cyrillic(input) {
+			t.Errorf("ChunkTransformer(%.20q) differs from whole conversion", input)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidSpans is returned by Restore when the spans do not fit the text.
+var ErrInvalidSpans = errors.New("spans do not match the text")
+
+// Span marks a part of converted text whose reverse conversion does not
+// give back the original, such as the Latin "obekt" for объект. Offset and
+// Length are in bytes of the converted text.
+type Span struct {
+	Offset   int
+	Length   int
+	Original string
+}
+
+// ConvertLossless converts text with convert and records a Span for every
+// word that reverse does not map back to itself. Words are the runs
+// between bytes of cutset, which neither conversion may carry state
+// across; the cut bytes themselves are copied unchanged.
+func ConvertLossless(text, cutset string, convert, reverse func(string) string) (string, []Span) {
+	var (
+		b     strings.Builder
+		spans []Span
+	)
+	b.Grow(len(text))
+
+	for len(text) > 0 {
+		end := strings.IndexAny(text, cutset)
+		if end < 0 {
+			end = len(text)
+		}
+		if word := text[:end]; word != "" {
+			out := convert(word)
+			if reverse(out) != word {
+				spans = append(spans, Span{Offset: b.Len(), Length: len(out), Original: word})
+			}
+			b.WriteString(out)
+		}
+		if end < len(text) {
+			b.WriteByte(text[end])
+			end++
+		}
+		text = text[end:]
+	}
+
+	return b.String(), spans
+}
+
+// Restore undoes ConvertLossless: the spans are replaced by their original
+// text and everything between them is converted back with reverse.
+func Restore(text string, spans []Span, reverse func(string) string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	pos := 0
+	for _, s := range spans {
+		if s.Offset < pos || s.Length < 0 || s.Offset+s.Length > len(text) {
+			return "", ErrInvalidSpans
+		}
+		b.WriteString(reverse(text[pos:s.Offset]))
+		b.WriteString(s.Original)
+		pos = s.Offset + s.Length
+	}
+	b.WriteString(reverse(text[pos:]))
+
+	return b.String(), nil
+}
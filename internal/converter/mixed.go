@@ -0,0 +1,123 @@
+package converter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// latinHomoglyphs maps Latin letters to the Cyrillic letters drawn the
+// same way in common fonts.
+var latinHomoglyphs = map[rune]rune{
+	'a': 'а', 'c': 'с', 'e': 'е', 'o': 'о', 'p': 'р', 'x': 'х', 'y': 'у',
+	'A': 'А', 'B': 'В', 'C': 'С', 'E': 'Е', 'H': 'Н', 'K': 'К', 'M': 'М',
+	'O': 'О', 'P': 'Р', 'T': 'Т', 'X': 'Х', 'Y': 'У',
+}
+
+// cyrillicHomoglyphs is latinHomoglyphs reversed.
+var cyrillicHomoglyphs = func() map[rune]rune {
+	m := make(map[rune]rune, len(latinHomoglyphs))
+	for lat, cyr := range latinHomoglyphs {
+		m[cyr] = lat
+	}
+	return m
+}()
+
+// MixedWord is a word that mixes Latin and Cyrillic letters, usually
+// because a look-alike letter from the other keyboard layout was typed.
+// Start and End are byte offsets of the word; Line and Column are 1-based
+// and count runes.
+type MixedWord struct {
+	Start, End   int
+	Line, Column int
+	Word         string
+	// Script is the script the word is meant to be in: "latin" or
+	// "cyrillic".
+	Script string
+	// Suggestion is the word with the look-alike letters of the other
+	// script replaced. Fixable reports whether that leaves no letter of
+	// the other script.
+	Suggestion string
+	Fixable    bool
+}
+
+// FindMixedScript returns the words of text that contain both Latin and
+// Cyrillic letters, with the intended spelling of each. A word is meant
+// to be in the script whose look-alike letters can all be replaced, or
+// failing that, the script of most of its letters.
+func FindMixedScript(text string) []MixedWord {
+	var words []MixedWord
+	line, col := 1, 1
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isWordRune(r) {
+			if r == '\n' {
+				line, col = line+1, 1
+			} else {
+				col++
+			}
+			i += size
+			continue
+		}
+
+		start, startCol := i, col
+		var c scriptCounter
+		for i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !isWordRune(r) {
+				break
+			}
+			c.add(r)
+			i += size
+			col++
+		}
+		if c.cyr == 0 || c.lat == 0 {
+			continue
+		}
+
+		word := text[start:i]
+		w := MixedWord{Start: start, End: i, Line: line, Column: startCol, Word: word}
+		cyr, cyrOK := replaceHomoglyphs(word, latinHomoglyphs, isLatin)
+		lat, latOK := replaceHomoglyphs(word, cyrillicHomoglyphs, isCyrillic)
+		if (cyrOK && !latOK) || (cyrOK == latOK && c.cyr >= c.lat) {
+			w.Script, w.Suggestion, w.Fixable = "cyrillic", cyr, cyrOK
+		} else {
+			w.Script, w.Suggestion, w.Fixable = "latin", lat, latOK
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// replaceHomoglyphs replaces the letters of word found in homoglyphs and
+// reports whether no letter for which from is true is left.
+func replaceHomoglyphs(word string, homoglyphs map[rune]rune, from func(rune) bool) (string, bool) {
+	var b strings.Builder
+	ok := true
+	for _, r := range word {
+		if repl, found := homoglyphs[r]; found {
+			r = repl
+		} else if from(r) {
+			ok = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), ok
+}
+
+// FixMixedScript replaces the look-alike letters of every mixed-script
+// word that can be written wholly in its intended script.
+func FixMixedScript(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, w := range FindMixedScript(text) {
+		if !w.Fixable {
+			continue
+		}
+		b.WriteString(text[last:w.Start])
+		b.WriteString(w.Suggestion)
+		last = w.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMixedScript(t *testing.T) {
+	// "Сaлем" has a Latin a, "Sаlem" a Cyrillic а, and "Қaзaқ" Latin a's.
+	text := "Сaлем dúnya\nSаlem, Қaзaқ xалық ǵo"
+	got := FindMixedScript(text)
+	want := []MixedWord{
+		{Start: 0, End: 9, Line: 1, Column: 1, Word: "Сaлем", Script: "cyrillic", Suggestion: "Салем", Fixable: true},
+		{Start: 17, End: 23, Line: 2, Column: 1, Word: "Sаlem", Script: "latin", Suggestion: "Salem", Fixable: true},
+		{Start: 25, End: 33, Line: 2, Column: 8, Word: "Қaзaқ", Script: "cyrillic", Suggestion: "Қазақ", Fixable: true},
+		{Start: 34, End: 43, Line: 2, Column: 14, Word: "xалық", Script: "cyrillic", Suggestion: "халық", Fixable: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindMixedScript =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFindMixedScriptUnfixable(t *testing.T) {
+	// ǵ has no Cyrillic look-alike and ғ no Latin one.
+	got := FindMixedScript("ǵғ")
+	if len(got) != 1 || got[0].Fixable {
+		t.Errorf("FindMixedScript(ǵғ) = %+v, want one unfixable word", got)
+	}
+}
+
+func TestFixMixedScript(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Сaлем, Sаlem!", "Салем, Salem!"},
+		{"ТOКСИКOЛOГИЯ", "ТОКСИКОЛОГИЯ"},
+		{"ǵғ және Сaлем", "ǵғ және Салем"},
+		{"no mixing here", "no mixing here"},
+	}
+	for _, tt := range tests {
+		if got := FixMixedScript(tt.in); got != tt.want {
+			t.Errorf("FixMixedScript(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
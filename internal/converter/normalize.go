@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// lookalikes maps letters that are typed in place of Karakalpak letters,
+// usually for want of a keyboard layout, to the letters they stand for.
+var lookalikes = map[rune]rune{
+	'ğ': 'ǵ', 'Ğ': 'Ǵ', // Turkish g with breve
+	'ǧ': 'ǵ', 'Ǧ': 'Ǵ', // g with caron
+	'ñ': 'ń', 'Ñ': 'Ń', // Spanish n with tilde
+	'ɩ': 'ı',           // Latin iota
+	'İ': 'I',           // Turkish capital dotted I
+	'һ': 'ҳ', 'Һ': 'Ҳ', // Bashkir, Kazakh and Tatar shha
+	'ҡ': 'қ', 'Ҡ': 'Қ', // Bashkir qa
+	'ӈ': 'ң', 'Ӈ': 'Ң', // en with hook
+}
+
+// maxSegment bounds how much input is held back waiting for the end of a
+// run of combining marks.
+const maxSegment = 128
+
+// Substitution records a character, or a letter with combining marks,
+// that was replaced before conversion. Line and Column are 1-based, in
+// runes of the input.
+type Substitution struct {
+	Line      int
+	Column    int
+	From      string
+	To        string
+	Lookalike bool // a look-alike letter was replaced, not just composed
+}
+
+// Normalizer is a streaming transform.Transformer that composes text to
+// NFC, so that a letter typed as a base letter and a combining accent
+// (a + U+0301) matches the precomposed letter in the tables. With
+// Lookalikes set it also replaces look-alike letters such as ğ, ñ and һ
+// with the Karakalpak letters ǵ, ń and ҳ. The zero value is ready to use.
+type Normalizer struct {
+	Lookalikes bool
+
+	subs   []Substitution
+	line   int
+	column int
+}
+
+// Reset implements transform.Transformer.
+func (n *Normalizer) Reset() {
+	*n = Normalizer{Lookalikes: n.Lookalikes}
+}
+
+// Substitutions returns the replacements made so far.
+func (n *Normalizer) Substitutions() []Substitution {
+	return n.subs
+}
+
+// Transform implements transform.Transformer.
+func (n *Normalizer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if n.line == 0 {
+		n.line = 1
+	}
+	for nSrc < len(src) {
+		end, ok := segmentEnd(src[nSrc:], atEOF)
+		if !ok {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		seg := src[nSrc : nSrc+end]
+
+		out := seg
+		if !isPlain(seg) && !norm.NFC.IsNormal(seg) {
+			out = norm.NFC.Bytes(seg)
+		}
+		lookalike := false
+		if r, size := utf8.DecodeRune(out); n.Lookalikes && size == len(out) {
+			if repl, ok := lookalikes[r]; ok {
+				out = utf8.AppendRune(nil, repl)
+				lookalike = true
+			}
+		}
+
+		if len(dst)-nDst < len(out) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		if string(out) != string(seg) {
+			n.subs = append(n.subs, Substitution{
+				Line:      n.line,
+				Column:    n.column + 1,
+				From:      string(seg),
+				To:        string(out),
+				Lookalike: lookalike,
+			})
+		}
+		nDst += copy(dst[nDst:], out)
+		nSrc += end
+
+		if seg[0] == '\n' {
+			n.line++
+			n.column = 0
+		} else {
+			n.column += utf8.RuneCount(seg)
+		}
+	}
+	return nDst, nSrc, nil
+}
+
+// segmentEnd returns the length of the leading normalization segment of
+// src: a rune and the combining marks that follow it. It reports false
+// when more input is needed to find the end.
+func segmentEnd(src []byte, atEOF bool) (int, bool) {
+	if !utf8.FullRune(src) && !atEOF {
+		return 0, false
+	}
+	_, end := utf8.DecodeRune(src)
+	for end < len(src) {
+		if !utf8.FullRune(src[end:]) {
+			if atEOF {
+				return len(src), true
+			}
+			return end, end >= maxSegment
+		}
+		if norm.NFC.Properties(src[end:]).BoundaryBefore() {
+			return end, true
+		}
+		_, size := utf8.DecodeRune(src[end:])
+		end += size
+	}
+	return end, atEOF || end >= maxSegment
+}
+
+// isPlain reports whether seg is a single ASCII byte, which NFC leaves
+// alone.
+func isPlain(seg []byte) bool {
+	return len(seg) == 1 && seg[0] < utf8.RuneSelf
+}
+
+// Normalize applies a Normalizer to text, replacing look-alike letters
+// if lookalikes is set.
+func Normalize(text string, lookalikes bool) (string, []Substitution) {
+	n := &Normalizer{Lookalikes: lookalikes}
+	out, _, err := transform.String(n, text)
+	if err != nil {
+		return text, nil
+	}
+	return out, n.Substitutions()
+}
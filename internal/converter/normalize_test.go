@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/transform"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Sa\u0301lem", "Sálem"},
+		{"g\u0301", "ǵ"},
+		{"N\u0301", "Ń"},
+		{"Dag\u0306", "Daǵ"},
+		{"Dağ tañ", "Daǵ tań"},
+		{"qɩz İnim", "qız Inim"},
+		{"и\u0306ол", "йол"},
+		{"һаўа ҡала", "ҳаўа қала"},
+		{"Sálem", "Sálem"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := Normalize(tt.in, true); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWithoutLookalikes(t *testing.T) {
+	in := "Sa\u0301lem Dağ İnim һаўа"
+	got, subs := Normalize(in, false)
+	if want := "Sálem Dağ İnim һаўа"; got != want {
+		t.Errorf("Normalize(%q, false) = %q, want %q", in, got, want)
+	}
+	if len(subs) != 1 || subs[0].Lookalike {
+		t.Errorf("substitutions = %+v, want only the composed á", subs)
+	}
+}
+
+func TestNormalizeSubstitutions(t *testing.T) {
+	_, subs := Normalize("sa\u0301lem\nDağ ha\u0301m", true)
+	want := []Substitution{
+		{Line: 1, Column: 2, From: "a\u0301", To: "á"},
+		{Line: 2, Column: 3, From: "ğ", To: "ǵ", Lookalike: true},
+		{Line: 2, Column: 6, From: "a\u0301", To: "á"},
+	}
+	if !reflect.DeepEqual(subs, want) {
+		t.Errorf("substitutions = %+v, want %+v", subs, want)
+	}
+}
+
+func TestNormalizerStream(t *testing.T) {
+	in := strings.Repeat("Sa\u0301lem, Dag\u0306! ", 3000)
+	want, _ := Normalize(in, true)
+
+	// The reader's 4 KiB buffer ends between letters and their accents.
+	got, err := io.ReadAll(transform.NewReader(strings.NewReader(in), &Normalizer{Lookalikes: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Error("streamed output differs from Normalize")
+	}
+}
@@ -0,0 +1,223 @@
+package converter
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dontbeidle/kaalin/internal/strutil"
+)
+
+// Round-trip guarantees of the letter tables:
+//
+//   - Latin → Cyrillic → Latin gives back any text written in the Latin
+//     alphabet, with words in lower case, Title case or UPPER case, as
+//     long as no UPPER-case word holds a digraph (SH, CH, YA, YU, YO):
+//     Ш is always spelled Sh, so SHAHAR reads back as ShAHAR.
+//   - Cyrillic → Latin → Cyrillic gives back any such text without ъ, ь,
+//     щ and э, which have no Latin letter of their own, and without the
+//     sequences сҳ, цҳ, йа, йу and йо, which read back as ш, ч, я, ю and ё.
+//   - ConvertLossless followed by Restore gives back any text.
+
+var (
+	latinLetters    = []rune("aábcdefgǵhiıjklmnńoópqrstuúvwxyz")
+	cyrillicLetters = []rune("аәбвгғдеёжзийкқлмнңоөпрстуүўфхҳцчшыюя")
+	separators      = []string{" ", " ", " ", "\n", ", ", ". ", "-", "\t", " 42 ", "! "}
+)
+
+// lossyCyrillic lists the Cyrillic letters the tables map to a Latin
+// letter that converts back to something else.
+var lossyCyrillic = map[rune]bool{
+	'ъ': true, 'Ъ': true, 'ь': true, 'Ь': true,
+	'щ': true, 'Щ': true, 'э': true, 'Э': true,
+}
+
+// randomText builds words from letters in lower, Title and UPPER case.
+func randomText(rng *rand.Rand, letters []rune, valid func(string) bool) string {
+	var b strings.Builder
+	words := 1 + rng.Intn(8)
+	for i := 0; i < words; i++ {
+		var word string
+		for {
+			w := make([]rune, 1+rng.Intn(8))
+			for j := range w {
+				w[j] = letters[rng.Intn(len(letters))]
+			}
+			word = string(w)
+			switch rng.Intn(3) {
+			case 1:
+				word = strutil.Upper(string(w[0])) + string(w[1:])
+			case 2:
+				word = strutil.Upper(word)
+			}
+			if valid(word) {
+				break
+			}
+		}
+		if i > 0 {
+			b.WriteString(separators[rng.Intn(len(separators))])
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// hasUpperDigraph reports whether word holds an upper-case digraph,
+// which reads back capitalized (SH → Ш → Sh).
+func hasUpperDigraph(word string) bool {
+	for _, d := range []string{"SH", "CH", "YA", "YU", "YO"} {
+		if strings.Contains(word, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAmbiguousCyrillic reports whether word contains a sequence whose
+// Latin spelling reads back as a single Cyrillic letter.
+func hasAmbiguousCyrillic(word string) bool {
+	lower := strutil.Lower(word)
+	for _, seq := range []string{"сҳ", "цҳ", "йа", "йу", "йо"} {
+		if strings.Contains(lower, seq) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLatinRoundTripProperty(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	valid := func(word string) bool {
+		// Latin text has no ambiguous digraphs: "sh" is always ш.
+		return !hasUpperDigraph(word)
+	}
+	for i := 0; i < 2000; i++ {
+		text := randomText(rng, latinLetters, valid)
+		cyr := Latin2Cyrillic(text)
+		if got := Cyrillic2Latin(cyr); got != text {
+			t.Fatalf("round trip of %q via %q = %q", text, cyr, got)
+		}
+	}
+}
+
+func TestCyrillicRoundTripProperty(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	valid := func(word string) bool {
+		return !hasAmbiguousCyrillic(word)
+	}
+	for i := 0; i < 2000; i++ {
+		text := randomText(rng, cyrillicLetters, valid)
+		lat := Cyrillic2Latin(text)
+		if got := Latin2Cyrillic(lat); got != text {
+			t.Fatalf("round trip of %q via %q = %q", text, lat, got)
+		}
+	}
+}
+
+func TestLosslessRoundTripProperty(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	letters := append(append([]rune{}, cyrillicLetters...), 'ъ', 'ь', 'щ', 'э', 'x', 'h')
+	for i := 0; i < 2000; i++ {
+		text := randomText(rng, letters, func(string) bool { return true })
+		lat, spans := ConvertLossless(text, " \t\r\n", Cyrillic2Latin, Latin2Cyrillic)
+		if lat != Cyrillic2Latin(text) {
+			t.Fatalf("ConvertLossless(%q) = %q, want %q", text, lat, Cyrillic2Latin(text))
+		}
+		got, err := Restore(lat, spans, Latin2Cyrillic)
+		if err != nil {
+			t.Fatalf("Restore(%q) returned error: %v", lat, err)
+		}
+		if got != text {
+			t.Fatalf("Restore(%q) = %q, want %q", lat, got, text)
+		}
+	}
+}
+
+// TestTableSymmetry checks that every letter converts back to itself,
+// apart from the documented lossy Cyrillic letters.
+func TestTableSymmetry(t *testing.T) {
+	for _, table := range []map[rune]string{latToCyrLower, latToCyrUpper} {
+		for lat, cyr := range table {
+			if back := Cyrillic2Latin(cyr); back != string(lat) {
+				t.Errorf("%q → %q → %q", lat, cyr, back)
+			}
+		}
+	}
+	for _, table := range []map[rune]string{cyrToLatLower, cyrToLatUpper} {
+		for cyr, lat := range table {
+			if lossyCyrillic[cyr] {
+				continue
+			}
+			if back := Latin2Cyrillic(lat); back != string(cyr) {
+				t.Errorf("%q → %q → %q", cyr, lat, back)
+			}
+		}
+	}
+}
+
+// TestAllCapsDigraphs documents that a letter spelled with a digraph is
+// always written capitalized, even inside an upper-case word, and that
+// such spellings read back as the same letter.
+func TestAllCapsDigraphs(t *testing.T) {
+	tests := []struct {
+		cyrillic string
+		latin    string
+	}{
+		{"ШАҲАР", "ShAHAR"},
+		{"ЧАҚҚАН ЯМАН", "ChAQQAN YaMAN"},
+		{"АШ", "ASh"},
+		{"Ш", "Sh"},
+		{"Шаҳар", "Shahar"},
+		{"ЮРТ!", "YuRT!"},
+	}
+	for _, tt := range tests {
+		if got := Cyrillic2Latin(tt.cyrillic); got != tt.latin {
+			t.Errorf("Cyrillic2Latin(%q) = %q, want %q", tt.cyrillic, got, tt.latin)
+		}
+		if got := Latin2Cyrillic(tt.latin); got != tt.cyrillic {
+			t.Errorf("Latin2Cyrillic(%q) = %q, want %q", tt.latin, got, tt.cyrillic)
+		}
+	}
+}
+
+func TestRestoreInvalidSpans(t *testing.T) {
+	spans := []Span{{Offset: 3, Length: 10, Original: "объект"}}
+	if _, err := Restore("obekt", spans, Latin2Cyrillic); err != ErrInvalidSpans {
+		t.Errorf("Restore with a span past the end: err = %v, want ErrInvalidSpans", err)
+	}
+}
+
+func FuzzLossless(f *testing.F) {
+	for _, seed := range []string{"объект", "Сәлем, дуньа!", "асҳаб йол", "Sálem", "ъ ь\n\tщ", "\xff\xfe"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, dir := range []struct{ convert, reverse func(string) string }{
+			{Cyrillic2Latin, Latin2Cyrillic},
+			{Latin2Cyrillic, Cyrillic2Latin},
+		} {
+			out, spans := ConvertLossless(text, " \t\r\n", dir.convert, dir.reverse)
+			got, err := Restore(out, spans, dir.reverse)
+			if err != nil {
+				t.Fatalf("Restore(%q) returned error: %v", out, err)
+			}
+			if got != text {
+				t.Fatalf("lossless round trip of %q = %q", text, got)
+			}
+		}
+	})
+}
+
+func FuzzTransformers(f *testing.F) {
+	for _, s := range transformSamples {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		if got, want := writeBytewise(t, Latin2CyrillicTransformer{}, text), Latin2Cyrillic(text); got != want {
+			t.Errorf("Latin2CyrillicTransformer(%q) = %q, want %q", text, got, want)
+		}
+		if got, want := writeBytewise(t, &Cyrillic2LatinTransformer{}, text), Cyrillic2Latin(text); got != want {
+			t.Errorf("Cyrillic2LatinTransformer(%q) = %q, want %q", text, got, want)
+		}
+	})
+}
@@ -182,7 +182,7 @@ var latToCyrLower = map[rune]string{
 	't': "т",
 	'u': "у",
 	'ú': "ү",
-	'v': "б",
+	'v': "в",
 	'w': "ў",
 	'y': "й",
 	'z': "з",
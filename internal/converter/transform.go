@@ -0,0 +1,275 @@
+package converter
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// Latin2CyrillicTransformer is a streaming transform.Transformer that
+// produces the same output as Latin2Cyrillic. Digraphs split across
+// buffer boundaries are held back until the next rune is available.
+type Latin2CyrillicTransformer struct {
+	transform.NopResetter
+}
+
+// Transform implements transform.Transformer.
+func (Latin2CyrillicTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r, size, ok := decodeRune(src[nSrc:], atEOF)
+		if !ok {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		repl := ""
+		consumed := size
+		if isDigraphStart(r) {
+			next, nextSize, ok := decodeRune(src[nSrc+size:], atEOF)
+			if !ok {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if nextSize > 0 {
+				if pair, found := latinPair(r, next); found {
+					repl = pair
+					consumed += nextSize
+				}
+			}
+		}
+		if consumed == size {
+			repl = latinRune(r)
+		}
+
+		if len(dst)-nDst < len(repl) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], repl)
+		nSrc += consumed
+	}
+	return nDst, nSrc, nil
+}
+
+// Cyrillic2LatinTransformer is a streaming transform.Transformer that
+// produces the same output as Cyrillic2Latin, including the ьи/ьо/ъе
+// rules across buffer boundaries. The zero value is ready to use.
+type Cyrillic2LatinTransformer struct {
+	prev    rune
+	started bool
+}
+
+// Reset implements transform.Transformer.
+func (t *Cyrillic2LatinTransformer) Reset() {
+	t.prev = 0
+	t.started = false
+}
+
+// Transform implements transform.Transformer.
+func (t *Cyrillic2LatinTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r, size, ok := decodeRune(src[nSrc:], atEOF)
+		if !ok {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		repl := ""
+		consumed := size
+		last := r
+		if (r == 'ь' || r == 'ъ') && t.started && !isWordBoundary(t.prev) {
+			next, nextSize, ok := decodeRune(src[nSrc+size:], atEOF)
+			if !ok {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if nextSize > 0 {
+				if pair, found := specialPair(r, next); found {
+					repl = pair
+					consumed += nextSize
+					last = next
+				}
+			}
+		}
+		if consumed == size {
+			repl = cyrillicRune(r)
+		}
+
+		if len(dst)-nDst < len(repl) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], repl)
+		nSrc += consumed
+		t.prev = last
+		t.started = true
+	}
+	return nDst, nSrc, nil
+}
+
+// decodeRune decodes the first rune of p. Invalid bytes decode to
+// utf8.RuneError, as when ranging over a string. ok is false when p ends
+// in an incomplete rune and more input may follow; an empty p at EOF
+// yields size 0.
+func decodeRune(p []byte, atEOF bool) (r rune, size int, ok bool) {
+	if len(p) == 0 {
+		return 0, 0, atEOF
+	}
+	if !atEOF && !utf8.FullRune(p) {
+		return 0, 0, false
+	}
+	r, size = utf8.DecodeRune(p)
+	return r, size, true
+}
+
+func latinRune(r rune) string {
+	if repl, ok := latToCyrUpper[r]; ok {
+		return repl
+	}
+	if repl, ok := latToCyrLower[r]; ok {
+		return repl
+	}
+	return string(r)
+}
+
+func cyrillicRune(r rune) string {
+	if repl, ok := cyrToLatUpper[r]; ok {
+		return repl
+	}
+	if repl, ok := cyrToLatLower[r]; ok {
+		return repl
+	}
+	return string(r)
+}
+
+// chunkMaxCarry bounds the input a ChunkTransformer carries over while
+// waiting for a cut point.
+const chunkMaxCarry = 64 << 10
+
+// ChunkTransformer streams a whole-string conversion such as
+// Dictionary.Latin2Cyrillic. Input is cut after whitespace, where no
+// digraph, ьи/ьо/ъе rule or dictionary word can straddle the cut, so the
+// output equals the conversion of the whole input. Input after the last
+// cut point is carried over until more whitespace or the end of input
+// arrives. Only a run of more than 64 KiB without one is cut early, after
+// its last character that is not part of a word, or failing that between
+// two characters.
+type ChunkTransformer struct {
+	convert func(string) string
+	cutset  string
+	carry   []byte
+	pending []byte
+}
+
+// NewChunkTransformer returns a ChunkTransformer applying convert to
+// whitespace-delimited chunks.
+func NewChunkTransformer(convert func(string) string) *ChunkTransformer {
+	return &ChunkTransformer{convert: convert, cutset: " \t\r\n"}
+}
+
+// NewLineChunkTransformer returns a ChunkTransformer that only cuts after
+// newlines, for conversions such as OldLatinToLatin whose state spans a
+// line.
+func NewLineChunkTransformer(convert func(string) string) *ChunkTransformer {
+	return &ChunkTransformer{convert: convert, cutset: "\n"}
+}
+
+// Reset implements transform.Transformer.
+func (t *ChunkTransformer) Reset() {
+	t.carry, t.pending = nil, nil
+}
+
+// Transform implements transform.Transformer.
+func (t *ChunkTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for {
+		if len(t.pending) > 0 {
+			n := copy(dst[nDst:], t.pending)
+			nDst += n
+			t.pending = t.pending[n:]
+			if len(t.pending) > 0 {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+		}
+
+		rest := src[nSrc:]
+		cut := len(rest)
+		if !atEOF {
+			cut = bytes.LastIndexAny(rest, t.cutset) + 1
+			if cut == 0 {
+				// No cut point: keep the partial chunk for later.
+				t.carry = append(t.carry, rest...)
+				nSrc = len(src)
+				if len(t.carry) < chunkMaxCarry {
+					return nDst, nSrc, nil
+				}
+				n := forcedCut(t.carry)
+				t.pending = append(t.pending[:0], t.convert(string(t.carry[:n]))...)
+				t.carry = append(t.carry[:0], t.carry[n:]...)
+				continue
+			}
+		} else if cut == 0 && len(t.carry) == 0 {
+			return nDst, nSrc, nil
+		}
+		chunk := string(t.carry) + string(rest[:cut])
+		t.carry = t.carry[:0]
+		t.pending = append(t.pending[:0], t.convert(chunk)...)
+		nSrc += cut
+	}
+}
+
+// forcedCut returns where to cut p, which has no cut point: after its
+// last rune that is neither a letter, a mark nor an apostrophe, or else
+// before its last rune, which may be incomplete.
+func forcedCut(p []byte) int {
+	for i := len(p); i > 0; {
+		r, size := utf8.DecodeLastRune(p[:i])
+		if r != utf8.RuneError && r != '\'' && !unicode.In(r, unicode.L, unicode.M) {
+			return i
+		}
+		i -= size
+	}
+	i := len(p) - 1
+	for i > 0 && !utf8.RuneStart(p[i]) {
+		i--
+	}
+	return i
+}
+
+// WholeTransformer buffers its entire input and converts it at EOF, for
+// document formats that cannot be converted piece by piece.
+type WholeTransformer struct {
+	convert func(string) (string, error)
+	buf     []byte
+	out     []byte
+	done    bool
+}
+
+// NewWholeTransformer returns a WholeTransformer applying convert to the
+// whole input.
+func NewWholeTransformer(convert func(string) (string, error)) *WholeTransformer {
+	return &WholeTransformer{convert: convert}
+}
+
+// Reset implements transform.Transformer.
+func (t *WholeTransformer) Reset() {
+	t.buf, t.out, t.done = nil, nil, false
+}
+
+// Transform implements transform.Transformer.
+func (t *WholeTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if !t.done {
+		t.buf = append(t.buf, src...)
+		nSrc = len(src)
+		if !atEOF {
+			return 0, nSrc, nil
+		}
+		out, err := t.convert(string(t.buf))
+		if err != nil {
+			return 0, nSrc, err
+		}
+		t.out, t.buf, t.done = []byte(out), nil, true
+	}
+
+	nDst = copy(dst, t.out)
+	t.out = t.out[nDst:]
+	if len(t.out) > 0 {
+		return nDst, nSrc, transform.ErrShortDst
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,127 @@
+package converter
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var transformSamples = []string{
+	"Assalawma áleykum",
+	"Sálem, dun'ya!",
+	"SHAHAR Shahar shahar chaqqan yaman yurt yoq",
+	"qırıq ǵarri ńaq",
+	"Ассалаўма әлейкум",
+	"обьиктив обьор объект кальций",
+	"СӘЛЕМ ЩИТ ЧАҚҚАН",
+	"ШАҲАР Ш, АШ ЁЛКА Шаҳар",
+	"ь и\nъ е",
+	"abc 123 def\n",
+	"invalid \xff bytes \xe2\x82",
+	"",
+}
+
+// writeBytewise feeds src through t one byte at a time so that every
+// digraph and multi-byte rune straddles a buffer boundary.
+func writeBytewise(t *testing.T, tr transform.Transformer, src string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := transform.NewWriter(&buf, tr)
+	for i := 0; i < len(src); i++ {
+		if _, err := w.Write([]byte{src[i]}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.String()
+}
+
+func TestLatin2CyrillicTransformer(t *testing.T) {
+	for _, input := range transformSamples {
+		want := Latin2Cyrillic(input)
+
+		got, _, err := transform.String(Latin2CyrillicTransformer{}, input)
+		if err != nil {
+			t.Fatalf("transform.String(%q): %v", input, err)
+		}
+		if got != want {
+			t.Errorf("Latin2CyrillicTransformer(%q) = %q, want %q", input, got, want)
+		}
+
+		if got := writeBytewise(t, Latin2CyrillicTransformer{}, input); got != want {
+			t.Errorf("Latin2CyrillicTransformer bytewise(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCyrillic2LatinTransformer(t *testing.T) {
+	for _, input := range transformSamples {
+		want := Cyrillic2Latin(input)
+
+		got, _, err := transform.String(&Cyrillic2LatinTransformer{}, input)
+		if err != nil {
+			t.Fatalf("transform.String(%q): %v", input, err)
+		}
+		if got != want {
+			t.Errorf("Cyrillic2LatinTransformer(%q) = %q, want %q", input, got, want)
+		}
+
+		if got := writeBytewise(t, &Cyrillic2LatinTransformer{}, input); got != want {
+			t.Errorf("Cyrillic2LatinTransformer bytewise(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestTransformerReset(t *testing.T) {
+	tr := &Cyrillic2LatinTransformer{}
+	if _, _, err := transform.String(tr, "об"); err != nil {
+		t.Fatal(err)
+	}
+	// transform.String resets the transformer, so ьи is at text start again.
+	got, _, err := transform.String(tr, "ьи")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Cyrillic2Latin("ьи"); got != want {
+		t.Errorf("after Reset: got %q, want %q", got, want)
+	}
+}
+
+func TestTransformerLargeInput(t *testing.T) {
+	input := strings.Repeat("Sálem, dun'ya! Shahar объект обьиктив ", 5000)
+	r := transform.NewReader(strings.NewReader(input), Latin2CyrillicTransformer{})
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != Latin2Cyrillic(input) {
+		t.Error("Latin2CyrillicTransformer output differs from Latin2Cyrillic on large input")
+	}
+
+	r = transform.NewReader(strings.NewReader(input), &Cyrillic2LatinTransformer{})
+	got, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != Cyrillic2Latin(input) {
+		t.Error("Cyrillic2LatinTransformer output differs from Cyrillic2Latin on large input")
+	}
+}
+
+func TestTransformerChainNFC(t *testing.T) {
+	// "Sálem" with a decomposed á (a + U+0301).
+	input := "Sa\u0301lem"
+	got, _, err := transform.String(transform.Chain(norm.NFC, Latin2CyrillicTransformer{}), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Сәлем"; got != want {
+		t.Errorf("Chain(NFC, Latin2Cyrillic)(%q) = %q, want %q", input, got, want)
+	}
+}
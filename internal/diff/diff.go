@@ -0,0 +1,247 @@
+// Package diff renders unified diffs of text rewrites.
+//
+// Script conversion changes lines but keeps their number, so when the old
+// and new texts have as many lines, line i of the old text is paired with
+// line i of the new text. This is exact for such rewrites and avoids the
+// cost of a general diff on files where nearly every line changes. When
+// the counts differ, as when fuzzy flags are added to a PO file, the lines
+// are matched with Myers' algorithm in linear space.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around a change.
+const DefaultContext = 3
+
+const noNewline = "\\ No newline at end of file\n"
+
+// edit is a line of the diff: kept (' '), removed ('-') or added ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// Unified returns a unified diff between old and new labelled with
+// oldName and newName, or "" if they are equal.
+func Unified(oldName, newName, old, new string, context int) string {
+	if old == new {
+		return ""
+	}
+
+	a := splitLines(old)
+	b := splitLines(new)
+	var edits []edit
+	if len(a) == len(b) {
+		edits = byPosition(a, b)
+	} else {
+		d := &differ{a: a, b: b}
+		d.diff(0, len(a), 0, len(b))
+		edits = d.edits
+	}
+
+	// oldAt and newAt count the old and new lines before each edit.
+	oldAt := make([]int, len(edits)+1)
+	newAt := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if e.op != '+' {
+			oldAt[i+1]++
+		}
+		if e.op != '-' {
+			newAt[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is within 2*context lines.
+		start := max(i-context, 0)
+		end := i + 1
+		for j := end; j < len(edits) && j < end+2*context+1; j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			}
+		}
+		stop := min(end+context, len(edits))
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(oldAt[start], oldAt[stop]-oldAt[start]),
+			hunkRange(newAt[start], newAt[stop]-newAt[start]))
+		writeHunk(&out, edits[start:stop])
+		i = stop
+	}
+
+	return out.String()
+}
+
+// byPosition pairs line i of a with line i of b, which have the same
+// length.
+func byPosition(a, b []string) []edit {
+	edits := make([]edit, 0, len(a))
+	for i := range a {
+		if a[i] == b[i] {
+			edits = append(edits, edit{' ', a[i]})
+			continue
+		}
+		edits = append(edits, edit{'-', a[i]}, edit{'+', b[i]})
+	}
+	return edits
+}
+
+// writeHunk prints each run of changed lines as all removals, then all
+// additions.
+func writeHunk(out *strings.Builder, edits []edit) {
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			writeLine(out, ' ', edits[i].line)
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(edits) && edits[j].op != ' ' {
+			j++
+		}
+		for _, op := range []byte{'-', '+'} {
+			for _, e := range edits[i:j] {
+				if e.op == op {
+					writeLine(out, op, e.line)
+				}
+			}
+		}
+		i = j
+	}
+}
+
+// differ finds the shortest edit script between a and b.
+type differ struct {
+	a, b  []string
+	edits []edit
+}
+
+// diff appends the edits that turn a[a0:a1] into b[b0:b1].
+func (d *differ) diff(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.edits = append(d.edits, edit{' ', d.a[a0]})
+		a0++
+		b0++
+	}
+	suffix := a1
+	for a1 > a0 && b1 > b0 && d.a[a1-1] == d.b[b1-1] {
+		a1--
+		b1--
+	}
+
+	switch {
+	case a0 == a1 || b0 == b1:
+		for _, line := range d.a[a0:a1] {
+			d.edits = append(d.edits, edit{'-', line})
+		}
+		for _, line := range d.b[b0:b1] {
+			d.edits = append(d.edits, edit{'+', line})
+		}
+	default:
+		xs, ys, xe, ye := d.middleSnake(a0, a1, b0, b1)
+		d.diff(a0, xs, b0, ys)
+		for _, line := range d.a[xs:xe] {
+			d.edits = append(d.edits, edit{' ', line})
+		}
+		d.diff(xe, a1, ye, b1)
+	}
+
+	for _, line := range d.a[a1:suffix] {
+		d.edits = append(d.edits, edit{' ', line})
+	}
+}
+
+// middleSnake returns the start and end of the middle snake of an
+// optimal path from (a0, b0) to (a1, b1), searching from both ends at
+// once as in Myers' "An O(ND) Difference Algorithm and Its Variations".
+// The ranges have no common first or last line.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (xs, ys, xe, ye int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+
+	// vf[k] is the furthest x reached on diagonal k = x-y going forward;
+	// vb[k] is the same going backward from (n, m), in reversed
+	// coordinates.
+	off := limit + 1
+	vf := make([]int, 2*limit+3)
+	vb := make([]int, 2*limit+3)
+
+	for D := 0; D <= limit; D++ {
+		for k := -D; k <= D; k += 2 {
+			x := vf[off+k-1] + 1
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			if kb := delta - k; odd && kb >= -(D-1) && kb <= D-1 && x+vb[off+kb] >= n {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y
+			}
+		}
+		for k := -D; k <= D; k += 2 {
+			x := vb[off+k-1] + 1
+			if k == -D || (k != D && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[a1-1-x] == d.b[b1-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -D && kf <= D && x+vf[off+kf] >= n {
+				return a1 - x, b1 - y, a1 - sx, b1 - sy
+			}
+		}
+	}
+	// Not reached: the paths meet by D = limit.
+	return a0, b0, a0, b0
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func writeLine(out *strings.Builder, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteByte('\n')
+		out.WriteString(noNewline)
+	}
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
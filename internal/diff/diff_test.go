@@ -0,0 +1,126 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"single line",
+			"Sálem\n",
+			"Сәлем\n",
+			"--- a\n+++ b\n@@ -1 +1 @@\n-Sálem\n+Сәлем\n",
+		},
+		{
+			"context",
+			"1\n2\n3\n4\nx\n5\n6\n7\n8\n",
+			"1\n2\n3\n4\ny\n5\n6\n7\n8\n",
+			"--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-x\n+y\n 5\n 6\n 7\n",
+		},
+		{
+			"merged hunks",
+			"x\n1\n2\n3\n4\n5\n6\nx\n",
+			"y\n1\n2\n3\n4\n5\n6\ny\n",
+			"--- a\n+++ b\n@@ -1,8 +1,8 @@\n-x\n+y\n 1\n 2\n 3\n 4\n 5\n 6\n-x\n+y\n",
+		},
+		{
+			"separate hunks",
+			"x\n1\n2\n3\n4\n5\n6\n7\nx\n",
+			"y\n1\n2\n3\n4\n5\n6\n7\ny\n",
+			"--- a\n+++ b\n@@ -1,4 +1,4 @@\n-x\n+y\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-x\n+y\n",
+		},
+		{
+			"no trailing newline",
+			"a",
+			"b",
+			"--- a\n+++ b\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			"added lines",
+			"a\n",
+			"a\nb\n",
+			"--- a\n+++ b\n@@ -1 +1,2 @@\n a\n+b\n",
+		},
+		{
+			// A flag line inserted before a changed line leaves the lines
+			// after it paired with themselves.
+			"inserted and changed",
+			"#: a.c\nmsgid \"x\"\nmsgstr \"sálem\"\n\n1\n2\n3\n4\n",
+			"#: a.c\n#, fuzzy\nmsgid \"x\"\nmsgstr \"сәлем\"\n\n1\n2\n3\n4\n",
+			"--- a\n+++ b\n@@ -1,6 +1,7 @@\n #: a.c\n+#, fuzzy\n msgid \"x\"\n-msgstr \"sálem\"\n+msgstr \"сәлем\"\n \n 1\n 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a", "b", tt.old, tt.new, DefaultContext)
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortestEdit(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a'+rng.Intn(3))) + "\n"
+		}
+		return lines
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := random(), random()
+		d := &differ{a: a, b: b}
+		d.diff(0, len(a), 0, len(b))
+
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range d.edits {
+			if e.op != '+' {
+				gotA = append(gotA, e.line)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.line)
+			}
+			if e.op != ' ' {
+				changes++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("edits of %q → %q do not rebuild the texts", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("edits of %q → %q make %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := range a {
+		prev := 0
+		for j := range b {
+			cur := row[j+1]
+			if a[i] == b[j] {
+				row[j+1] = prev + 1
+			} else {
+				row[j+1] = max(row[j+1], row[j])
+			}
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
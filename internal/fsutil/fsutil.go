@@ -0,0 +1,81 @@
+// Package fsutil provides safe file replacement.
+package fsutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic replaces path with the output of write. The data goes to a
+// temporary file in the same directory which is renamed over path, so
+// readers never see a partial file. An existing file keeps its permission
+// bits; new files get 0644. Symlinks are followed and their target is
+// replaced. If backupSuffix is not empty, the original content is kept at
+// path+backupSuffix.
+func WriteAtomic(path, backupSuffix string, write func(io.Writer) error) error {
+	mode := os.FileMode(0644)
+	exists := false
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		mode = info.Mode().Perm()
+		exists = true
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if exists && backupSuffix != "" {
+		if err := backup(path, path+backupSuffix, mode); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// backup keeps the current content of path at dst, as a hard link when
+// possible and as a copy otherwise.
+func backup(path, dst string, mode os.FileMode) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(path, dst); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,453 @@
+// Package markdown converts the prose of Markdown documents while keeping
+// code, URLs, link targets, comments and all formatting byte for byte.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/markup"
+)
+
+// Options selects what is converted besides prose, link text and alt
+// text.
+type Options struct {
+	// FrontMatter lists the front-matter fields whose values are
+	// converted, such as title and description. Keys are never converted.
+	FrontMatter []string
+
+	// HTML is used for HTML blocks.
+	HTML markup.Options
+}
+
+var (
+	fenceLine     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	listItem      = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(\s|$)`)
+	taskItem      = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])\s+\[[ xX]\]\s`)
+	htmlBlock     = regexp.MustCompile(`^ {0,3}</?[A-Za-z][A-Za-z0-9-]*(\s|/?>|$)`)
+	linkReference = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	autolink      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*|[^\s@<>]+@[^\s@<>]+)>`)
+	inlineTag     = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(\s[^<>]*)?/?>`)
+	frontField    = regexp.MustCompile(`^([A-Za-z0-9_-]+)(\s*[:=][ \t]*)(.*?)(\r?\n)?$`)
+	escapeSeq     = regexp.MustCompile(`\\(x[0-9a-fA-F]{2}|u[0-9a-fA-F]{4}|U[0-9a-fA-F]{8}|.)`)
+)
+
+// Convert applies convert to the prose of the Markdown document src:
+// paragraphs, headings, list items, quotes, tables, link text and image
+// alt text. Code blocks, code spans, URLs, link destinations and
+// references, HTML comments and front matter are copied unchanged, apart
+// from the front-matter fields named in opts. HTML blocks are converted
+// with opts.HTML.
+func Convert(src string, convert func(string) string, opts Options) string {
+	c := &docConverter{
+		raw:     convert,
+		convert: func(s string) string { return markup.ConvertText(s, convert) },
+		opts:    opts,
+	}
+	c.b.Grow(len(src))
+	c.run(strings.SplitAfter(src, "\n"))
+	return c.b.String()
+}
+
+type docConverter struct {
+	raw     func(string) string
+	convert func(string) string
+	opts    Options
+	b       strings.Builder
+}
+
+func (c *docConverter) run(lines []string) {
+	lines = c.frontMatter(lines)
+
+	var para strings.Builder
+	flush := func() {
+		c.inline(para.String())
+		para.Reset()
+	}
+
+	prevBlank, inList := true, false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		blank := strings.TrimSpace(line) == ""
+
+		switch {
+		case blank:
+			flush()
+			c.b.WriteString(line)
+
+		case fenceLine.MatchString(line):
+			flush()
+			fence := strings.TrimLeft(fenceLine.FindString(line), " ")
+			c.b.WriteString(line)
+			for i+1 < len(lines) {
+				i++
+				c.b.WriteString(lines[i])
+				if closesFence(lines[i], fence) {
+					break
+				}
+			}
+
+		case indentWidth(line) >= 4 && prevBlank && !inList:
+			flush()
+			c.b.WriteString(line)
+			for i+1 < len(lines) && (indentWidth(lines[i+1]) >= 4 || strings.TrimSpace(lines[i+1]) == "") {
+				i++
+				c.b.WriteString(lines[i])
+			}
+
+		case strings.HasPrefix(strings.TrimLeft(line, " "), "<!--"):
+			flush()
+			c.b.WriteString(line)
+			for !strings.Contains(line, "-->") && i+1 < len(lines) {
+				i++
+				line = lines[i]
+				c.b.WriteString(line)
+			}
+
+		case htmlBlock.MatchString(line):
+			flush()
+			var block strings.Builder
+			block.WriteString(line)
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+				block.WriteString(lines[i])
+			}
+			c.b.WriteString(markup.Convert(block.String(), c.raw, c.opts.HTML))
+
+		case linkReference.MatchString(line):
+			flush()
+			c.b.WriteString(line)
+
+		case taskItem.MatchString(line):
+			flush()
+			prefix := taskItem.FindString(line)
+			c.b.WriteString(prefix)
+			para.WriteString(line[len(prefix):])
+
+		default:
+			para.WriteString(line)
+		}
+
+		switch {
+		case blank:
+		case listItem.MatchString(line):
+			inList = true
+		case indentWidth(line) == 0:
+			inList = false
+		}
+		prevBlank = blank
+	}
+	flush()
+}
+
+// closesFence reports whether line closes a code block opened by fence.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < len(fence) || indentWidth(line) > 3 {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == "" && strings.HasPrefix(trimmed, fence)
+}
+
+// indentWidth returns the indentation of line in columns, with tabs
+// stopping at multiples of four.
+func indentWidth(line string) int {
+	w := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			w++
+		case '\t':
+			w += 4 - w%4
+		default:
+			return w
+		}
+	}
+	return w
+}
+
+// frontMatter copies a leading YAML (---) or TOML (+++) block, converting
+// the values of the selected fields, and returns the remaining lines.
+func (c *docConverter) frontMatter(lines []string) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+	delim := strings.TrimRight(lines[0], "\r\n")
+	if delim != "---" && delim != "+++" {
+		return lines
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimRight(lines[i], "\r\n"); l == delim || (delim == "---" && l == "...") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return lines
+	}
+
+	c.b.WriteString(lines[0])
+	for i := 1; i < end; i++ {
+		line := lines[i]
+		m := frontField.FindStringSubmatchIndex(line)
+		if m == nil || !c.frontField(line[m[2]:m[3]]) {
+			c.b.WriteString(line)
+			continue
+		}
+
+		value := line[m[6]:m[7]]
+		c.b.WriteString(line[:m[6]])
+		c.b.WriteString(c.scalar(value))
+		c.b.WriteString(line[m[7]:])
+
+		// Block scalars (|, >) and lists continue on indented lines.
+		block := strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">")
+		for i+1 < end && indentWidth(lines[i+1]) > 0 {
+			next := lines[i+1]
+			item := strings.HasPrefix(strings.TrimLeft(next, " \t"), "- ")
+			if !block && !(value == "" && item) {
+				break
+			}
+			i++
+			c.b.WriteString(c.scalar(next))
+		}
+	}
+	c.b.WriteString(lines[end])
+	return lines[end+1:]
+}
+
+func (c *docConverter) frontField(key string) bool {
+	for _, f := range c.opts.FrontMatter {
+		if strings.EqualFold(f, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// scalar converts a front-matter value, keeping backslash escapes of
+// double-quoted strings.
+func (c *docConverter) scalar(s string) string {
+	if !strings.HasPrefix(strings.TrimSpace(s), `"`) {
+		return c.raw(s)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range escapeSeq.FindAllStringIndex(s, -1) {
+		b.WriteString(c.raw(s[last:m[0]]))
+		b.WriteString(s[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(c.raw(s[last:]))
+	return b.String()
+}
+
+// inline converts the prose of a paragraph and copies its code spans,
+// URLs, link destinations and inline HTML.
+func (c *docConverter) inline(s string) {
+	prose := 0
+	keep := func(start, end int) {
+		c.b.WriteString(c.convert(s[prose:start]))
+		c.b.WriteString(s[start:end])
+		prose = end
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\\':
+			i += 2
+
+		case '`':
+			n := runLen(s[i:], '`')
+			if end := closingBackticks(s, i+n, n); end >= 0 {
+				keep(i, end)
+				i = end
+			} else {
+				i += n
+			}
+
+		case '<':
+			n := 0
+			switch {
+			case strings.HasPrefix(s[i:], "<!--"):
+				n = strings.Index(s[i:], "-->")
+				if n >= 0 {
+					n += len("-->")
+				} else {
+					n = len(s) - i
+				}
+			case autolink.MatchString(s[i:]):
+				n = len(autolink.FindString(s[i:]))
+			case inlineTag.MatchString(s[i:]):
+				n = len(inlineTag.FindString(s[i:]))
+			}
+			if n > 0 {
+				keep(i, i+n)
+				i += n
+			} else {
+				i++
+			}
+
+		case '!', '[':
+			start := i
+			if s[i] == '!' {
+				if i+1 >= len(s) || s[i+1] != '[' {
+					i++
+					continue
+				}
+				i++
+			}
+			close := closingBracket(s, i)
+			if close < 0 {
+				i++
+				continue
+			}
+			if strings.HasPrefix(s[i:], "[^") {
+				// Footnote reference.
+				keep(start, close+1)
+				i = close + 1
+				continue
+			}
+
+			keep(start, i+1)
+			c.inline(s[i+1 : close])
+			end := close + 1
+			switch {
+			case end < len(s) && s[end] == '(':
+				if dest := closingParen(s, end); dest >= 0 {
+					end = dest + 1
+				}
+			case end < len(s) && s[end] == '[':
+				if ref := strings.IndexByte(s[end:], ']'); ref >= 0 {
+					end += ref + 1
+				}
+			}
+			prose = close
+			keep(close, end)
+			i = end
+
+		case 'h', 'w', 'H', 'W':
+			if n := bareURLLen(s, i); n > 0 {
+				keep(i, i+n)
+				i += n
+			} else {
+				i++
+			}
+
+		default:
+			i++
+		}
+	}
+	c.b.WriteString(c.convert(s[prose:]))
+}
+
+func runLen(s string, ch byte) int {
+	n := 0
+	for n < len(s) && s[n] == ch {
+		n++
+	}
+	return n
+}
+
+// closingBackticks returns the end of the code span whose opening run of n
+// backticks ends at from, or -1.
+func closingBackticks(s string, from, n int) int {
+	for i := from; i < len(s); {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		run := runLen(s[i:], '`')
+		if run == n {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
+
+// closingBracket returns the index of the ] matching the [ at s[open],
+// skipping escapes and code spans, or -1.
+func closingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			n := runLen(s[i:], '`')
+			if end := closingBackticks(s, i+n, n); end >= 0 {
+				i = end - 1
+			} else {
+				i += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\n':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// closingParen returns the index of the ) ending the link destination
+// that opens at s[open], or -1.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '<':
+			if j := strings.IndexByte(s[i:], '>'); j >= 0 && i == open+1 {
+				i += j
+			}
+		case '"', '\'':
+			if depth == 1 {
+				if j := strings.IndexByte(s[i+1:], s[i]); j >= 0 {
+					i += j + 1
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// bareURLLen returns the length of a URL starting at s[i] that is not
+// part of a word, without trailing punctuation, or 0.
+func bareURLLen(s string, i int) int {
+	if i > 0 && !strings.ContainsRune(" \t\n(*_~", rune(s[i-1])) {
+		return 0
+	}
+	rest := strings.ToLower(s[i:min(len(s), i+8)])
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") && !strings.HasPrefix(rest, "www.") {
+		return 0
+	}
+	n := strings.IndexAny(s[i:], " \t\n<")
+	if n < 0 {
+		n = len(s) - i
+	}
+	for n > 0 && strings.ContainsRune(".,:;!?*_~'\")", rune(s[i+n-1])) {
+		if s[i+n-1] == ')' && strings.Count(s[i:i+n], "(") >= strings.Count(s[i:i+n], ")") {
+			break
+		}
+		n--
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dontbeidle/kaalin/internal/markup"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"paragraph", "# Title\n\nSome *text* here.\n", "# TITLE\n\nSOME *TEXT* HERE.\n"},
+		{"inline code", "run `go test` now", "RUN `go test` NOW"},
+		{"double backticks", "a ``x ` y`` b", "A ``x ` y`` B"},
+		{"fenced code", "a\n```go\nfunc main() {}\n```\nb\n", "A\n```go\nfunc main() {}\n```\nB\n"},
+		{"tilde fence", "~~~~\ncode\n~~~\nstill\n~~~~\nb", "~~~~\ncode\n~~~\nstill\n~~~~\nB"},
+		{"indented code", "a\n\n    code\n\nb", "A\n\n    code\n\nB"},
+		{"list continuation", "- item\n\n    more text", "- ITEM\n\n    MORE TEXT"},
+		{"link", "see [the docs](https://example.com/docs \"Title\") now", "SEE [THE DOCS](https://example.com/docs \"Title\") NOW"},
+		{"nested parens", "[a](x_(y)) b", "[A](x_(y)) B"},
+		{"image", "![alt text](img/path.png)", "![ALT TEXT](img/path.png)"},
+		{"reference link", "[text][ref] and [ref]\n\n[ref]: https://example.com \"t\"\n", "[TEXT][ref] AND [REF]\n\n[ref]: https://example.com \"t\"\n"},
+		{"footnote", "word[^note] end", "WORD[^note] END"},
+		{"autolink", "mail <me@example.com> or <https://x.org>", "MAIL <me@example.com> OR <https://x.org>"},
+		{"bare url", "go to https://example.com/path.", "GO TO https://example.com/path."},
+		{"www url", "(www.example.com) ok", "(www.example.com) OK"},
+		{"inline html", "a <span class=\"x\">b</span> c", "A <span class=\"x\">B</span> C"},
+		{"html comment", "a <!-- note --> b\n<!--\nblock\n-->\nc", "A <!-- note --> B\n<!--\nblock\n-->\nC"},
+		{"html block", "<div class=\"note\">\ntext\n</div>\n\npara", "<div class=\"note\">\nTEXT\n</div>\n\nPARA"},
+		{"entity", "a &amp; b", "A &amp; B"},
+		{"escape", "\\`not code\\` x", "\\`NOT CODE\\` X"},
+		{"task list", "- [x] done\n- [ ] todo", "- [x] DONE\n- [ ] TODO"},
+		{"table", "| a | b |\n|---|---|\n| c | `d` |", "| A | B |\n|---|---|\n| C | `d` |"},
+		{"crlf", "a\r\n```\r\nx\r\n```\r\nb", "A\r\n```\r\nx\r\n```\r\nB"},
+		{"front matter", "---\ntitle: hello\nslug: hello\n---\nbody", "---\ntitle: hello\nslug: hello\n---\nBODY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, upper, Options{}); got != tt.want {
+				t.Errorf("Convert(%q) =\n%q\nwant\n%q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFrontMatter(t *testing.T) {
+	opts := Options{FrontMatter: []string{"title", "description", "tags"}}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"yaml", "---\ntitle: hello\nslug: hello\n---\n", "---\ntitle: HELLO\nslug: hello\n---\n"},
+		{"quoted", "---\ntitle: \"a\\nb\"\n---\n", "---\ntitle: \"A\\nB\"\n---\n"},
+		{"block scalar", "---\ndescription: |\n  line one\n  line two\nslug: x\n---\n", "---\ndescription: |\n  LINE ONE\n  LINE TWO\nslug: x\n---\n"},
+		{"list", "---\ntags:\n  - one\n  - two\nslug: x\n---\n", "---\ntags:\n  - ONE\n  - TWO\nslug: x\n---\n"},
+		{"toml", "+++\ntitle = \"hello\"\n+++\n", "+++\ntitle = \"HELLO\"\n+++\n"},
+		{"unterminated", "---\ntitle: hello\n", "---\nTITLE: HELLO\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, upper, opts); got != tt.want {
+				t.Errorf("Convert(%q) =\n%q\nwant\n%q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLBlockAttributes(t *testing.T) {
+	opts := Options{HTML: markup.Options{Attrs: []string{"alt"}}}
+	input := "<img src=\"a.png\" alt=\"picture\">\n"
+	want := "<img src=\"a.png\" alt=\"PICTURE\">\n"
+	if got := Convert(input, upper, opts); got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+}
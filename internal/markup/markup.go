@@ -0,0 +1,334 @@
+// Package markup converts the text of HTML and XML documents while
+// leaving the markup byte for byte as it was.
+package markup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Options selects what is converted.
+type Options struct {
+	// XML applies XML rules: element names are case-sensitive, CDATA
+	// sections are text, and no element holds raw text. Otherwise the
+	// HTML rules apply and the content of script and style is left alone.
+	XML bool
+
+	// Attrs lists the attributes whose values are converted, such as
+	// title or alt.
+	Attrs []string
+
+	// Skip lists elements whose content is left alone.
+	Skip []string
+
+	// Only, if set, limits conversion to the content of these elements,
+	// such as the target elements of an XLIFF file.
+	Only []string
+
+	// Lang is the script subtag, "Latn" or "Cyrl", written into lang and
+	// xml:lang attributes that name Karakalpak in a script. Empty leaves
+	// them unchanged.
+	Lang string
+
+	// LangAttrs lists further attributes that hold language tags, such
+	// as target-language.
+	LangAttrs []string
+}
+
+// rawTextElements hold text that is not markup in HTML.
+var rawTextElements = []string{"script", "style"}
+
+// entity matches character references, which are copied unchanged.
+var entity = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+
+// Convert applies convert to the text nodes of src and to the values of
+// the attributes in opts.Attrs. Tags, comments, processing instructions,
+// character references and all other bytes are copied unchanged.
+func Convert(src string, convert func(string) string, opts Options) string {
+	c := &docConverter{convert: convert, opts: opts}
+	c.b.Grow(len(src))
+	c.run(src)
+	return c.b.String()
+}
+
+type docConverter struct {
+	convert func(string) string
+	opts    Options
+	b       strings.Builder
+	skip    []string // open elements whose content is left alone
+	only    []string // open elements listed in Options.Only
+}
+
+func (c *docConverter) run(src string) {
+	for len(src) > 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt < 0 {
+			c.text(src)
+			return
+		}
+		c.text(src[:lt])
+		src = src[lt:]
+
+		var n int
+		switch {
+		case strings.HasPrefix(src, "<!--"):
+			n = until(src, "-->")
+			c.b.WriteString(src[:n])
+
+		case strings.HasPrefix(src, "<![CDATA["):
+			n = until(src, "]]>")
+			if c.opts.XML && c.converting() && strings.HasSuffix(src[:n], "]]>") {
+				c.b.WriteString("<![CDATA[")
+				c.b.WriteString(c.convert(src[len("<![CDATA[") : n-len("]]>")]))
+				c.b.WriteString("]]>")
+			} else {
+				c.b.WriteString(src[:n])
+			}
+
+		case strings.HasPrefix(src, "<!"), strings.HasPrefix(src, "<?"):
+			n = until(src, ">")
+			c.b.WriteString(src[:n])
+
+		case strings.HasPrefix(src, "</"):
+			n = until(src, ">")
+			c.endTag(tagName(src[2:n]))
+			c.b.WriteString(src[:n])
+
+		case len(src) > 1 && isNameStart(src[1]):
+			var name string
+			var selfClosing bool
+			n, name, selfClosing = c.startTag(src)
+			if selfClosing {
+				break
+			}
+			if !c.opts.XML && c.hasName(rawTextElements, name) {
+				m := rawTextLen(src[n:], name)
+				c.b.WriteString(src[n : n+m])
+				n += m
+			} else if c.hasName(c.opts.Skip, name) {
+				c.skip = append(c.skip, name)
+			} else if c.hasName(c.opts.Only, name) {
+				c.only = append(c.only, name)
+			}
+
+		default:
+			n = 1
+			c.text("<")
+		}
+		src = src[n:]
+	}
+}
+
+// text converts a text node, keeping character references.
+func (c *docConverter) text(s string) {
+	if !c.converting() {
+		c.b.WriteString(s)
+		return
+	}
+	c.b.WriteString(ConvertText(s, c.convert))
+}
+
+// ConvertText applies convert to s around its character references, such
+// as &amp; or &#233;, which are kept unchanged.
+func ConvertText(s string, convert func(string) string) string {
+	if s == "" {
+		return ""
+	}
+	refs := entity.FindAllStringIndex(s, -1)
+	if refs == nil {
+		return convert(s)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, ref := range refs {
+		b.WriteString(convert(s[last:ref[0]]))
+		b.WriteString(s[ref[0]:ref[1]])
+		last = ref[1]
+	}
+	b.WriteString(convert(s[last:]))
+	return b.String()
+}
+
+// startTag copies the start tag at the beginning of src, converting the
+// selected attribute values, and returns its length and name.
+func (c *docConverter) startTag(src string) (n int, name string, selfClosing bool) {
+	i := 1
+	for i < len(src) && !isSpace(src[i]) && src[i] != '>' && src[i] != '/' {
+		i++
+	}
+	name = src[1:i]
+
+	last := 0
+	for i < len(src) {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			break
+		}
+		if src[i] == '>' {
+			i++
+			break
+		}
+		if src[i] == '/' {
+			if i+1 < len(src) && src[i+1] == '>' {
+				selfClosing = true
+				i += 2
+				break
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' &&
+			!(src[i] == '/' && i+1 < len(src) && src[i+1] == '>') {
+			i++
+		}
+		attr := src[start:i]
+
+		j := i
+		for j < len(src) && isSpace(src[j]) {
+			j++
+		}
+		if j >= len(src) || src[j] != '=' {
+			continue
+		}
+		j++
+		for j < len(src) && isSpace(src[j]) {
+			j++
+		}
+
+		var vs, ve int
+		if j < len(src) && (src[j] == '"' || src[j] == '\'') {
+			vs = j + 1
+			ve = strings.IndexByte(src[vs:], src[j])
+			if ve < 0 {
+				ve = len(src)
+				i = ve
+			} else {
+				ve += vs
+				i = ve + 1
+			}
+		} else {
+			vs = j
+			ve = j
+			for ve < len(src) && !isSpace(src[ve]) && src[ve] != '>' {
+				ve++
+			}
+			i = ve
+		}
+
+		if value, ok := c.attrValue(attr, src[vs:ve]); ok {
+			c.b.WriteString(src[last:vs])
+			c.b.WriteString(value)
+			last = ve
+		}
+	}
+
+	c.b.WriteString(src[last:i])
+	return i, name, selfClosing
+}
+
+// attrValue returns the new value of an attribute, if it changes.
+func (c *docConverter) attrValue(attr, value string) (string, bool) {
+	if c.opts.Lang != "" && (strings.EqualFold(attr, "lang") || attr == "xml:lang" || c.hasName(c.opts.LangAttrs, attr)) {
+		return LangTag(value, c.opts.Lang)
+	}
+	if c.converting() && c.hasName(c.opts.Attrs, attr) {
+		return ConvertText(value, c.convert), true
+	}
+	return "", false
+}
+
+// LangTag replaces the script subtag of a Karakalpak language tag such as
+// kaa-Latn or kaa-Cyrl-UZ.
+func LangTag(tag, script string) (string, bool) {
+	subtags := strings.Split(tag, "-")
+	if !strings.EqualFold(subtags[0], "kaa") {
+		return "", false
+	}
+	for i, s := range subtags[1:] {
+		if strings.EqualFold(s, "Latn") || strings.EqualFold(s, "Cyrl") {
+			subtags[i+1] = script
+			return strings.Join(subtags, "-"), true
+		}
+	}
+	return "", false
+}
+
+// converting reports whether text at the current position is converted.
+func (c *docConverter) converting() bool {
+	return len(c.skip) == 0 && (len(c.opts.Only) == 0 || len(c.only) > 0)
+}
+
+// endTag closes a skipped element or one listed in Options.Only.
+func (c *docConverter) endTag(name string) {
+	if n := len(c.skip); n > 0 && c.sameName(c.skip[n-1], name) {
+		c.skip = c.skip[:n-1]
+	} else if n := len(c.only); n > 0 && c.sameName(c.only[n-1], name) {
+		c.only = c.only[:n-1]
+	}
+}
+
+func (c *docConverter) hasName(names []string, name string) bool {
+	for _, n := range names {
+		if c.sameName(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameName compares element or attribute names, ignoring case in HTML.
+func (c *docConverter) sameName(a, b string) bool {
+	if c.opts.XML {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// tagName returns the name at the start of the inside of an end tag.
+func tagName(s string) string {
+	i := 0
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+		i++
+	}
+	return s[:i]
+}
+
+// until returns the length of src up to and including end, or all of src
+// if end does not occur.
+func until(src, end string) int {
+	if i := strings.Index(src, end); i >= 0 {
+		return i + len(end)
+	}
+	return len(src)
+}
+
+// rawTextLen returns the length of the raw text before the end tag of
+// name, matched without regard to case.
+func rawTextLen(src, name string) int {
+	for i := 0; ; {
+		j := strings.Index(src[i:], "</")
+		if j < 0 {
+			return len(src)
+		}
+		k := i + j + len("</")
+		if e := k + len(name); e <= len(src) && strings.EqualFold(src[k:e], name) {
+			if e == len(src) || isSpace(src[e]) || src[e] == '>' || src[e] == '/' {
+				return i + j
+			}
+		}
+		i = k
+	}
+}
+
+func isNameStart(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_' || b == ':' || b >= 0x80
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
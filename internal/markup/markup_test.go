@@ -0,0 +1,76 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func TestConvertHTML(t *testing.T) {
+	opts := Options{Attrs: []string{"title", "alt", "placeholder"}, Lang: "Cyrl", Skip: []string{"code"}}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"text", "<p>sálem</p>", "<p>SÁLEM</p>"},
+		{"attributes", `<img alt="súwret" src="a.png" title='qala' data-x=y>`, `<img alt="SÚWRET" src="a.png" title='QALA' data-x=y>`},
+		{"unquoted attribute", `<input placeholder=atı class=x>`, `<input placeholder=ATI class=x>`},
+		{"upper-case names", `<IMG ALT="a">b`, `<IMG ALT="A">B`},
+		{"entities", "a &amp; b&nbsp;c &#233; &#x41;", "A &amp; B&nbsp;C &#233; &#x41;"},
+		{"comment", "<!-- sálem -->x", "<!-- sálem -->X"},
+		{"doctype", "<!DOCTYPE html>x", "<!DOCTYPE html>X"},
+		{"script and style", "<script>var a = '<b>';</script><STYLE>p{}</STYLE >x", "<script>var a = '<b>';</script><STYLE>p{}</STYLE >X"},
+		{"skipped element", "<code>a<b>c</b></code>d", "<code>a<b>c</b></code>D"},
+		{"lang", `<html lang="kaa-Latn"><p lang="en">x</p>`, `<html lang="kaa-Cyrl"><p lang="en">X</p>`},
+		{"lang region", `<p lang="KAA-latn-UZ">`, `<p lang="KAA-Cyrl-UZ">`},
+		{"lone less-than", "a < b", "A < B"},
+		{"self-closing", "a<br/>b", "A<br/>B"},
+		{"unterminated", "a<p title='x", "A<p title='X"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, upper, opts); got != tt.want {
+				t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertXML(t *testing.T) {
+	opts := Options{XML: true, Attrs: []string{"title"}, Lang: "Latn"}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"declaration", `<?xml version="1.0"?><a>x</a>`, `<?xml version="1.0"?><a>X</a>`},
+		{"cdata", "<a><![CDATA[x < y]]></a>", "<a><![CDATA[X < Y]]></a>"},
+		{"case-sensitive attributes", `<a title="x" Title="y"/>`, `<a title="X" Title="y"/>`},
+		{"no raw text", "<script>x</script>", "<script>X</script>"},
+		{"xml:lang", `<a xml:lang="kaa-Cyrl">`, `<a xml:lang="kaa-Latn">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, upper, opts); got != tt.want {
+				t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertOnly(t *testing.T) {
+	opts := Options{XML: true, Only: []string{"target"}, Skip: []string{"ph"}, LangAttrs: []string{"target-language"}, Lang: "Cyrl"}
+	input := `<file target-language="kaa-Latn"><source>a</source><target>b <ph>x</ph> <![CDATA[c]]></target><note>d</note></file>`
+	want := `<file target-language="kaa-Cyrl"><source>a</source><target>B <ph>x</ph> <![CDATA[C]]></target><note>d</note></file>`
+	if got := Convert(input, upper, opts); got != want {
+		t.Errorf("Convert(%q) =\n%q\nwant\n%q", input, got, want)
+	}
+}
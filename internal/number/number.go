@@ -1,9 +1,21 @@
 package number
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidNumber is returned for NaN, infinite and malformed inputs.
+	ErrInvalidNumber = errors.New("not a valid number")
+
+	// ErrOutOfRange is returned when the number is 10^33 or larger in
+	// magnitude, past what the largest scale word, nonillion, can count.
+	ErrOutOfRange = errors.New("number exceeds maximum allowed value (max: 10^33)")
 )
 
 var onesLat = []string{"nol", "bir", "eki", "úsh", "tórt", "bes", "altı", "jeti", "segiz", "toǵız"}
@@ -14,6 +26,7 @@ var thousandsLat = []string{"", "mıń", "million", "milliard", "trillion", "kva
 const hundredLat = "júz"
 const minusLat = "minus"
 const putinLat = "pútin"
+const procentLat = "procent"
 
 var onesCyr = []string{"ноль", "бир", "еки", "үш", "төрт", "бес", "алты", "жети", "сегиз", "тоғыз"}
 var teensCyr = []string{"он бир", "он еки", "он үш", "он төрт", "он бес", "он алты", "он жети", "он сегиз", "он тоғыз"}
@@ -23,45 +36,58 @@ var thousandsCyr = []string{"", "мың", "миллион", "миллиард",
 const hundredCyr = "жүз"
 const minusCyr = "минус"
 const putinCyr = "пүтін"
+const procentCyr = "процент"
+
+// maxDigits is the number of integer digits the scale words can name:
+// three for each entry of thousandsLat, up to 999 nonillion.
+var maxDigits = 3 * len(thousandsLat)
 
-// Maximum value: 10^30
-const maxValue = 1e30
+// maxFractionDigits is the number of fractional digits the denominators
+// can name, up to júz nonillionnan for 10^32.
+var maxFractionDigits = maxDigits - 1
 
 // ToWord converts a number to its Karakalpak word representation.
-// script should be "lat" (default) or "cyr".
+// script should be "lat" (default) or "cyr". The number is spelled as the
+// shortest decimal that reads back as the same float64, so 0.1 is "nol
+// pútin onnan bir"; integers beyond 2^53 cannot be held exactly in a
+// float64, and DecimalToWord spells every digit.
 func ToWord(number float64, script string) (string, error) {
 	if math.IsInf(number, 0) || math.IsNaN(number) {
-		return "", fmt.Errorf("\"%.0f\" is not a valid number", number)
+		return "", fmt.Errorf("\"%.0f\" is %w", number, ErrInvalidNumber)
 	}
+	return DecimalToWord(strconv.FormatFloat(number, 'f', -1, 64), script)
+}
 
-	negative := false
-	if number < 0 {
-		negative = true
-		number = -number
+// DecimalToWord converts a number written in decimal, such as "-12.75" or
+// "12345678901234567890", to its Karakalpak word representation. Every
+// digit is spelled; an exponent, as in "1.5e6", is also accepted.
+// script should be "lat" (default) or "cyr".
+func DecimalToWord(decimal string, script string) (string, error) {
+	negative, intDigits, fracDigits, ok := parseDecimal(decimal)
+	if !ok {
+		return "", fmt.Errorf("%q is %w", decimal, ErrInvalidNumber)
 	}
-
-	if number >= maxValue {
-		return "", fmt.Errorf("number exceeds maximum allowed value (max: 10^30)")
+	if len(intDigits) > maxDigits {
+		return "", ErrOutOfRange
+	}
+	if len(fracDigits) > maxFractionDigits {
+		return "", fmt.Errorf("%q has more than %d fractional digits: %w", decimal, maxFractionDigits, ErrOutOfRange)
 	}
 
 	isCyr := script == "cyr"
 
-	// Split into integer and fractional parts
-	intPart := int64(number)
-	fracStr := extractFraction(number)
-
 	var result string
-	if fracStr != "" {
+	if fracDigits != "" {
 		// Fractional number
-		intWord := convertInteger(intPart, isCyr)
-		fracWord, denomWord := convertFraction(fracStr, isCyr)
+		intWord := convertInteger(intDigits, isCyr)
+		fracWord, denomWord := convertFraction(fracDigits, isCyr)
 		putin := putinLat
 		if isCyr {
 			putin = putinCyr
 		}
 		result = intWord + " " + putin + " " + denomWord + " " + fracWord
 	} else {
-		result = convertInteger(intPart, isCyr)
+		result = convertInteger(intDigits, isCyr)
 	}
 
 	if negative {
@@ -75,17 +101,64 @@ func ToWord(number float64, script string) (string, error) {
 	return result, nil
 }
 
-func extractFraction(number float64) string {
-	s := fmt.Sprintf("%.10f", number)
-	parts := strings.SplitN(s, ".", 2)
-	if len(parts) < 2 {
-		return ""
+// parseDecimal splits a decimal number into its sign, its integer digits
+// without leading zeros ("0" for none) and its fractional digits without
+// trailing zeros. An exponent moves the decimal point. Negative zero has
+// no sign.
+func parseDecimal(s string) (negative bool, intDigits, fracDigits string, ok bool) {
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > 1000 || e < -1000 {
+			return false, "", "", false
+		}
+		exp, s = e, s[:i]
 	}
-	frac := strings.TrimRight(parts[1], "0")
-	return frac
+
+	intDigits, fracDigits, _ = strings.Cut(s, ".")
+	if intDigits == "" && fracDigits == "" || !isDigits(intDigits) || !isDigits(fracDigits) {
+		return false, "", "", false
+	}
+
+	if exp > 0 {
+		fracDigits += strings.Repeat("0", max(0, exp-len(fracDigits)))
+		intDigits, fracDigits = intDigits+fracDigits[:exp], fracDigits[exp:]
+	} else if exp < 0 {
+		intDigits = strings.Repeat("0", max(0, -exp-len(intDigits))) + intDigits
+		cut := len(intDigits) + exp
+		intDigits, fracDigits = intDigits[:cut], intDigits[cut:]+fracDigits
+	}
+
+	intDigits = strings.TrimLeft(intDigits, "0")
+	if intDigits == "" {
+		intDigits = "0"
+	}
+	fracDigits = strings.TrimRight(fracDigits, "0")
+	if intDigits == "0" && fracDigits == "" {
+		negative = false
+	}
+	return negative, intDigits, fracDigits, true
 }
 
-func convertInteger(n int64, isCyr bool) string {
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// convertInteger spells the decimal digits of a whole number, which have
+// no leading zeros.
+func convertInteger(digits string, isCyr bool) string {
 	ones := onesLat
 	teens := teensLat
 	tens := tensLat
@@ -99,26 +172,25 @@ func convertInteger(n int64, isCyr bool) string {
 		hundred = hundredCyr
 	}
 
-	if n == 0 {
+	if digits == "0" {
 		return ones[0]
 	}
 
 	// Special case: exactly 100 → "júz" / "жүз"
-	if n == 100 {
+	if digits == "100" {
 		return hundred
 	}
 
 	// Special case: exactly 1000 → "mıń" / "мың"
-	if n == 1000 {
+	if digits == "1000" {
 		return thousands[1]
 	}
 
 	var parts []string
 	groupIndex := 0
 
-	for n > 0 {
-		group := int(n % 1000)
-		n /= 1000
+	for end := len(digits); end > 0; end -= 3 {
+		group, _ := strconv.Atoi(digits[max(0, end-3):end])
 
 		if group != 0 {
 			groupWord := convertHundreds(group, ones, teens, tens, hundred)
@@ -137,9 +209,6 @@ func convertInteger(n int64, isCyr bool) string {
 		}
 
 		groupIndex++
-		if groupIndex >= len(thousands) {
-			break
-		}
 	}
 
 	return strings.Join(parts, " ")
@@ -181,73 +250,227 @@ func convertHundreds(n int, ones, teens, tens []string, hundred string) string {
 }
 
 func convertFraction(fracStr string, isCyr bool) (string, string) {
-	// Parse the fractional digits as an integer
-	fracNum := int64(0)
-	for _, c := range fracStr {
-		fracNum = fracNum*10 + int64(c-'0')
-	}
-
 	// Denominator is 10^len(fracStr)
 	denomPow := len(fracStr)
 	denomWord := getDenominator(denomPow, isCyr)
 
+	// The numerator is the fractional digits read as a whole number.
+	fracNum := strings.TrimLeft(fracStr, "0")
 	fracWord := convertInteger(fracNum, isCyr)
 	return fracWord, denomWord
 }
 
+// FractionToWord converts a common fraction such as "3/4", or a mixed
+// number such as "2 1/2", to its Karakalpak word representation: the
+// denominator in the ablative case and then the numerator, so "3/4" is
+// "tórtten úsh" and "2 1/2" is "eki pútin ekiden bir".
+// script should be "lat" (default) or "cyr".
+func FractionToWord(text string, script string) (string, error) {
+	negative, whole, numerator, denominator, err := parseCommonFraction(text)
+	if err != nil {
+		return "", err
+	}
+
+	isCyr := script == "cyr"
+	result := convertCommonFraction(numerator, denominator, isCyr)
+	if whole != "" {
+		putin := putinLat
+		if isCyr {
+			putin = putinCyr
+		}
+		result = convertInteger(whole, isCyr) + " " + putin + " " + result
+	}
+
+	if negative {
+		prefix := minusLat
+		if isCyr {
+			prefix = minusCyr
+		}
+		result = prefix + " " + result
+	}
+
+	return result, nil
+}
+
+// PercentToWord converts a percentage such as "15%" or "12.5%" to its
+// Karakalpak word representation, "on bes procent".
+// script should be "lat" (default) or "cyr".
+func PercentToWord(text string, script string) (string, error) {
+	decimal, ok := strings.CutSuffix(strings.TrimSpace(text), "%")
+	if !ok {
+		return "", fmt.Errorf("%q is not a percentage: %w", text, ErrInvalidNumber)
+	}
+	words, err := DecimalToWord(strings.TrimSpace(decimal), script)
+	if err != nil {
+		return "", err
+	}
+	if script == "cyr" {
+		return words + " " + procentCyr, nil
+	}
+	return words + " " + procentLat, nil
+}
+
+// parseCommonFraction splits "[-][whole ]numerator/denominator" into its
+// parts, as digits without leading zeros. whole is empty for a plain
+// fraction.
+func parseCommonFraction(text string) (negative bool, whole, numerator, denominator string, err error) {
+	s := strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		negative, s = true, rest
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return false, "", "", "", fmt.Errorf("%q is %w", text, ErrInvalidNumber)
+	}
+	if len(fields) == 2 {
+		whole = fields[0]
+	}
+	numerator, denominator, ok := strings.Cut(fields[len(fields)-1], "/")
+	if !ok {
+		return false, "", "", "", fmt.Errorf("%q is %w", text, ErrInvalidNumber)
+	}
+
+	if whole != "" {
+		if whole, err = fractionPart(whole, text); err != nil {
+			return false, "", "", "", err
+		}
+	}
+	if numerator, err = fractionPart(numerator, text); err != nil {
+		return false, "", "", "", err
+	}
+	if denominator, err = fractionPart(denominator, text); err != nil {
+		return false, "", "", "", err
+	}
+
+	if denominator == "0" {
+		return false, "", "", "", fmt.Errorf("%q has a zero denominator: %w", text, ErrInvalidNumber)
+	}
+	if numerator == "0" && (whole == "" || whole == "0") {
+		negative = false
+	}
+	if whole != "" && compareDigits(numerator, denominator) >= 0 {
+		return false, "", "", "", fmt.Errorf("%q is not a mixed number; its fraction must be less than one: %w", text, ErrInvalidNumber)
+	}
+	return negative, whole, numerator, denominator, nil
+}
+
+// fractionPart checks that part of the fraction text is a whole number
+// and returns its digits without leading zeros.
+func fractionPart(part, text string) (string, error) {
+	if part == "" || !isDigits(part) {
+		return "", fmt.Errorf("%q is %w", text, ErrInvalidNumber)
+	}
+	if len(part) > maxDigits {
+		return "", ErrOutOfRange
+	}
+	if part = strings.TrimLeft(part, "0"); part == "" {
+		part = "0"
+	}
+	return part, nil
+}
+
+// compareDigits compares two whole numbers given as digits without
+// leading zeros.
+func compareDigits(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// convertCommonFraction spells numerator/denominator as the denominator
+// in the ablative case followed by the numerator: tórtten úsh.
+func convertCommonFraction(numerator, denominator string, isCyr bool) string {
+	denomWord := convertInteger(denominator, isCyr)
+	last := denomWord[strings.LastIndexByte(denomWord, ' ')+1:]
+	denomWord += ablativeSuffix(last, isCyr)
+	return denomWord + " " + convertInteger(numerator, isCyr)
+}
+
+// getDenominator returns the word for 10^power in the ablative case, the
+// denominator of a decimal fraction with power digits: onnan, júzden,
+// mıńnan, on mıńnan, júz mıńnan, millionnan and so on.
 func getDenominator(power int, isCyr bool) string {
+	tens, thousands, hundred := tensLat, thousandsLat, hundredLat
 	if isCyr {
-		return getDenominatorCyr(power)
+		tens, thousands, hundred = tensCyr, thousandsCyr, hundredCyr
 	}
-	return getDenominatorLat(power)
-}
 
-func getDenominatorLat(power int) string {
-	switch power {
+	var words []string
+	switch power % 3 {
 	case 1:
-		return "onnan"
+		words = append(words, tens[0])
 	case 2:
-		return "júzden"
-	case 3:
-		return "mıńnan"
-	case 4:
-		return "on mıńnan"
-	case 5:
-		return "júz mıńnan"
-	case 6:
-		return "millionnan"
-	case 7:
-		return "on millionnan"
-	case 8:
-		return "júz millionnan"
-	case 9:
-		return "milliardtan"
-	default:
-		return "onnan"
-	}
-}
-
-func getDenominatorCyr(power int) string {
-	switch power {
-	case 1:
-		return "оннан"
-	case 2:
-		return "жүзден"
-	case 3:
-		return "мыңнан"
-	case 4:
-		return "он мыңнан"
-	case 5:
-		return "жүз мыңнан"
-	case 6:
-		return "миллионнан"
-	case 7:
-		return "он миллионнан"
-	case 8:
-		return "жүз миллионнан"
-	case 9:
-		return "миллиардтан"
-	default:
-		return "оннан"
+		words = append(words, hundred)
+	}
+	if scale := thousands[power/3]; scale != "" {
+		words = append(words, scale)
+	}
+	last := len(words) - 1
+	words[last] += ablativeSuffix(words[last], isCyr)
+	return strings.Join(words, " ")
+}
+
+// Vowels by harmony class. Suffixes take their vowel from the last
+// vowel of the word they follow.
+const (
+	backVowelsLat  = "aoıu"
+	frontVowelsLat = "áóúei"
+	backVowelsCyr  = "аоыу"
+	frontVowelsCyr = "әөүеи"
+)
+
+// isFront reports whether the last vowel of word is a front vowel, so
+// that suffixes after it take e and i rather than a and ı.
+func isFront(word string, isCyr bool) bool {
+	back, front := backVowelsLat, frontVowelsLat
+	if isCyr {
+		back, front = backVowelsCyr, frontVowelsCyr
+	}
+	result := false
+	for _, r := range word {
+		switch {
+		case strings.ContainsRune(back, r):
+			result = false
+		case strings.ContainsRune(front, r):
+			result = true
+		}
+	}
+	return result
+}
+
+// endsInVowel reports whether the last letter of word is a vowel.
+func endsInVowel(word string, isCyr bool) bool {
+	vowels := backVowelsLat + frontVowelsLat
+	if isCyr {
+		vowels = backVowelsCyr + frontVowelsCyr
+	}
+	last, _ := utf8.DecodeLastRuneInString(word)
+	return strings.ContainsRune(vowels, last)
+}
+
+// ablativeSuffix returns the ablative ending of word, -dan, -nan or -tan
+// and their front-vowel forms. The consonant is n after a nasal, d after
+// a vowel or a sonorant and t otherwise, so milliard takes -tan.
+func ablativeSuffix(word string, isCyr bool) string {
+	nasals, voiced := "mnń", "lrwyzj"
+	suffix := [2][3]string{{"dan", "nan", "tan"}, {"den", "nen", "ten"}}
+	if isCyr {
+		nasals, voiced = "мнң", "лрўйзж"
+		suffix = [2][3]string{{"дан", "нан", "тан"}, {"ден", "нен", "тен"}}
+	}
+
+	harmony := 0
+	if isFront(word, isCyr) {
+		harmony = 1
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(word)
+	switch {
+	case strings.ContainsRune(nasals, last):
+		return suffix[harmony][1]
+	case endsInVowel(word, isCyr) || strings.ContainsRune(voiced, last):
+		return suffix[harmony][0]
 	}
+	return suffix[harmony][2]
 }
@@ -1,6 +1,10 @@
 package number
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestBasicNumbers(t *testing.T) {
 	tests := []struct {
@@ -111,9 +115,9 @@ func TestCyrillic(t *testing.T) {
 }
 
 func TestOverflow(t *testing.T) {
-	_, err := ToWord(1e31, "lat")
+	_, err := ToWord(1e34, "lat")
 	if err == nil {
-		t.Error("ToWord(1e31) should return error for overflow")
+		t.Error("ToWord(1e34) should return error for overflow")
 	}
 }
 
@@ -141,3 +145,190 @@ func TestSpecialCases(t *testing.T) {
 		})
 	}
 }
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"12345678901234567890", "on eki kvintillion úsh júz qırıq bes kvadrillion altı júz jetpis segiz trillion toǵız júz bir milliard eki júz otız tórt million bes júz alpıs jeti mıń segiz júz toqsan"},
+		{"9007199254740993", "toǵız kvadrillion jeti trillion bir júz toqsan toǵız milliard eki júz eliw tórt million jeti júz qırıq mıń toǵız júz toqsan úsh"},
+		{"999999999999999999999999999999999", "toǵız júz toqsan toǵız nonillion toǵız júz toqsan toǵız oktillion toǵız júz toqsan toǵız septillion toǵız júz toqsan toǵız sekstilion toǵız júz toqsan toǵız kvintillion toǵız júz toqsan toǵız kvadrillion toǵız júz toqsan toǵız trillion toǵız júz toqsan toǵız milliard toǵız júz toqsan toǵız million toǵız júz toqsan toǵız mıń toǵız júz toqsan toǵız"},
+		{"007", "jeti"},
+		{"+5", "bes"},
+		{"-0", "nol"},
+		{"-12.750", "minus on eki pútin júzden jetpis bes"},
+		{".5", "nol pútin onnan bes"},
+		{"1.5e3", "mıń bes júz"},
+		{"25e-1", "eki pútin onnan bes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := DecimalToWord(tt.input, "lat")
+			if err != nil {
+				t.Fatalf("DecimalToWord(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecimalToWord(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalErrors(t *testing.T) {
+	for _, input := range []string{"", "-", ".", "12,5", "1.2.3", "0x10", "1e", "abc"} {
+		if _, err := DecimalToWord(input, "lat"); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("DecimalToWord(%q): err = %v, want ErrInvalidNumber", input, err)
+		}
+	}
+	if _, err := DecimalToWord("1"+strings.Repeat("0", 33), "lat"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("DecimalToWord(10^33): err = %v, want ErrOutOfRange", err)
+	}
+}
+
+func TestDenominator(t *testing.T) {
+	lat := []string{"onnan", "júzden", "mıńnan", "on mıńnan", "júz mıńnan", "millionnan",
+		"on millionnan", "júz millionnan", "milliardtan", "on milliardtan", "júz milliardtan", "trillionnan"}
+	cyr := []string{"оннан", "жүзден", "мыңнан", "он мыңнан", "жүз мыңнан", "миллионнан",
+		"он миллионнан", "жүз миллионнан", "миллиардтан", "он миллиардтан", "жүз миллиардтан", "триллионнан"}
+
+	for i := range lat {
+		if got := getDenominator(i+1, false); got != lat[i] {
+			t.Errorf("getDenominator(%d, lat) = %q, want %q", i+1, got, lat[i])
+		}
+		if got := getDenominator(i+1, true); got != cyr[i] {
+			t.Errorf("getDenominator(%d, cyr) = %q, want %q", i+1, got, cyr[i])
+		}
+	}
+	if got, want := getDenominator(maxFractionDigits, false), "júz nonillionnan"; got != want {
+		t.Errorf("getDenominator(%d, lat) = %q, want %q", maxFractionDigits, got, want)
+	}
+}
+
+func TestFractionDigits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input float64
+		want  string
+	}{
+		{"0.1", 0.1, "nol pútin onnan bir"},
+		{"2.675", 2.675, "eki pútin mıńnan altı júz jetpis bes"},
+		{"1.23456789012", 1.23456789012, "bir pútin júz milliardtan jigirma úsh milliard tórt júz eliw altı million jeti júz seksen toǵız mıń on eki"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToWord(tt.input, "lat")
+			if err != nil {
+				t.Fatalf("ToWord(%v, lat) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToWord(%v, lat) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalFraction(t *testing.T) {
+	tests := []struct {
+		input  string
+		script string
+		want   string
+	}{
+		{"0.1", "lat", "nol pútin onnan bir"},
+		{"2.675", "cyr", "еки пүтін мыңнан алты жүз жетпис бес"},
+		{"0.0000000001", "lat", "nol pútin on milliardtan bir"},
+		{"0.000000000001", "cyr", "ноль пүтін триллионнан бир"},
+		{"3.14159265358979", "lat", "úsh pútin júz trillionnan on tórt trillion bir júz eliw toǵız milliard eki júz alpıs bes million úsh júz eliw segiz mıń toǵız júz jetpis toǵız"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := DecimalToWord(tt.input, tt.script)
+			if err != nil {
+				t.Fatalf("DecimalToWord(%q, %q) returned error: %v", tt.input, tt.script, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecimalToWord(%q, %q) = %q, want %q", tt.input, tt.script, got, tt.want)
+			}
+			if back, err := FromWords(got); err != nil || back != tt.input {
+				t.Errorf("FromWords(%q) = %q, %v, want %q", got, back, err, tt.input)
+			}
+		})
+	}
+
+	if _, err := DecimalToWord("0."+strings.Repeat("1", maxFractionDigits+1), "lat"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("DecimalToWord with %d fractional digits: err = %v, want ErrOutOfRange", maxFractionDigits+1, err)
+	}
+}
+
+func TestCommonFraction(t *testing.T) {
+	tests := []struct {
+		input  string
+		script string
+		want   string
+	}{
+		{"3/4", "lat", "tórtten úsh"},
+		{"1/2", "lat", "ekiden bir"},
+		{"2/3", "lat", "úshten eki"},
+		{"5/6", "lat", "altıdan bes"},
+		{"1/100", "lat", "júzden bir"},
+		{"7/1000", "lat", "mıńnan jeti"},
+		{"9/20", "lat", "jigirmadan toǵız"},
+		{"2 1/2", "lat", "eki pútin ekiden bir"},
+		{"-3/4", "lat", "minus tórtten úsh"},
+		{"3/4", "cyr", "төрттен үш"},
+		{"1 5/8", "cyr", "бир пүтін сегизден бес"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.script, func(t *testing.T) {
+			got, err := FractionToWord(tt.input, tt.script)
+			if err != nil {
+				t.Fatalf("FractionToWord(%q, %q) returned error: %v", tt.input, tt.script, err)
+			}
+			if got != tt.want {
+				t.Errorf("FractionToWord(%q, %q) = %q, want %q", tt.input, tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonFractionErrors(t *testing.T) {
+	for _, input := range []string{"3/0", "3/", "/4", "3/4/5", "1.5/2", "2 3/2", "1 2 3/4", "abc"} {
+		if _, err := FractionToWord(input, "lat"); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("FractionToWord(%q): err = %v, want ErrInvalidNumber", input, err)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		input  string
+		script string
+		want   string
+	}{
+		{"15%", "lat", "on bes procent"},
+		{"100 %", "lat", "júz procent"},
+		{"12.5%", "lat", "on eki pútin onnan bes procent"},
+		{"-3%", "lat", "minus úsh procent"},
+		{"15%", "cyr", "он бес процент"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.script, func(t *testing.T) {
+			got, err := PercentToWord(tt.input, tt.script)
+			if err != nil {
+				t.Fatalf("PercentToWord(%q, %q) returned error: %v", tt.input, tt.script, err)
+			}
+			if got != tt.want {
+				t.Errorf("PercentToWord(%q, %q) = %q, want %q", tt.input, tt.script, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := PercentToWord("15", "lat"); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("PercentToWord(15): err = %v, want ErrInvalidNumber", err)
+	}
+}
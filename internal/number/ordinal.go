@@ -0,0 +1,79 @@
+package number
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToOrdinal converts a whole number written in decimal to its Karakalpak
+// ordinal numeral: "1" is "birinshi", "6" is "altınshı" and "123" is "bir
+// júz jigirma úshinshi". The suffix goes on the last word; it is -nshi
+// after a vowel and -inshi after a consonant, with ı for back vowels.
+// script should be "lat" (default) or "cyr".
+func ToOrdinal(decimal string, script string) (string, error) {
+	digits, err := parseWhole(decimal)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) > maxDigits {
+		return "", ErrOutOfRange
+	}
+
+	isCyr := script == "cyr"
+	words := convertInteger(digits, isCyr)
+	i := strings.LastIndexByte(words, ' ') + 1
+	last := words[i:]
+	if stem, ok := ordinalStems[last]; ok {
+		last = stem
+	}
+	return words[:i] + last + ordinalSuffix(last, isCyr), nil
+}
+
+// ordinalStems holds the words that drop a vowel before the ordinal
+// suffix: qırıq becomes qırqınshı.
+var ordinalStems = map[string]string{
+	tensLat[3]: "qırq",
+	tensCyr[3]: "қырқ",
+}
+
+// AbbreviateOrdinal returns the short written form of the ordinal of a
+// whole number written in decimal, its digits and a hyphen: "1-", "25-".
+func AbbreviateOrdinal(decimal string) (string, error) {
+	digits, err := parseWhole(decimal)
+	if err != nil {
+		return "", err
+	}
+	return digits + "-", nil
+}
+
+// parseWhole returns the digits of a whole number that is not negative.
+func parseWhole(decimal string) (string, error) {
+	negative, intDigits, fracDigits, ok := parseDecimal(decimal)
+	if !ok {
+		return "", fmt.Errorf("%q is %w", decimal, ErrInvalidNumber)
+	}
+	if negative || fracDigits != "" {
+		return "", fmt.Errorf("%q is not a whole number of 0 or more: %w", decimal, ErrInvalidNumber)
+	}
+	return intDigits, nil
+}
+
+// ordinalSuffix returns the ordinal ending of word.
+func ordinalSuffix(word string, isCyr bool) string {
+	suffix := "ınshı"
+	switch {
+	case isCyr && isFront(word, isCyr):
+		suffix = "инши"
+	case isCyr:
+		suffix = "ыншы"
+	case isFront(word, isCyr):
+		suffix = "inshi"
+	}
+	if endsInVowel(word, isCyr) {
+		// The word's own vowel stands in for the first one of the suffix.
+		_, size := utf8.DecodeRuneInString(suffix)
+		suffix = suffix[size:]
+	}
+	return suffix
+}
@@ -0,0 +1,70 @@
+package number
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrdinal(t *testing.T) {
+	tests := []struct {
+		input  string
+		script string
+		want   string
+	}{
+		{"1", "lat", "birinshi"},
+		{"2", "lat", "ekinshi"},
+		{"3", "lat", "úshinshi"},
+		{"6", "lat", "altınshı"},
+		{"10", "lat", "onınshı"},
+		{"20", "lat", "jigirmanshı"},
+		{"40", "lat", "qırqınshı"},
+		{"100", "lat", "júzinshi"},
+		{"1000", "lat", "mıńınshı"},
+		{"123", "lat", "bir júz jigirma úshinshi"},
+		{"2000000", "lat", "eki millionınshı"},
+		{"1", "cyr", "биринши"},
+		{"2", "cyr", "екинши"},
+		{"3", "cyr", "үшинши"},
+		{"6", "cyr", "алтыншы"},
+		{"100", "cyr", "жүзинши"},
+		{"1000", "cyr", "мыңыншы"},
+		{"25", "cyr", "жигирма бесинши"},
+		{"70", "cyr", "жетписинши"},
+		{"140", "cyr", "бир жүз қырқыншы"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.script, func(t *testing.T) {
+			got, err := ToOrdinal(tt.input, tt.script)
+			if err != nil {
+				t.Fatalf("ToOrdinal(%q, %q) returned error: %v", tt.input, tt.script, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToOrdinal(%q, %q) = %q, want %q", tt.input, tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbreviateOrdinal(t *testing.T) {
+	for input, want := range map[string]string{"1": "1-", "25": "25-", "007": "7-", "3.0": "3-"} {
+		got, err := AbbreviateOrdinal(input)
+		if err != nil {
+			t.Fatalf("AbbreviateOrdinal(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("AbbreviateOrdinal(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestOrdinalErrors(t *testing.T) {
+	for _, input := range []string{"-1", "2.5", "abc"} {
+		if _, err := ToOrdinal(input, "lat"); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("ToOrdinal(%q): err = %v, want ErrInvalidNumber", input, err)
+		}
+		if _, err := AbbreviateOrdinal(input); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("AbbreviateOrdinal(%q): err = %v, want ErrInvalidNumber", input, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package number
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/strutil"
+)
+
+// ErrInvalidWords is returned, wrapped in a *WordsError, when text is not
+// a number spelled out in words.
+var ErrInvalidWords = errors.New("not a number in words")
+
+// WordsError reports where a number in words stops making sense.
+type WordsError struct {
+	// Pos is the 1-based index of the offending word, or 0 when the text
+	// as a whole is at fault.
+	Pos    int
+	Word   string
+	Reason string
+}
+
+func (e *WordsError) Error() string {
+	if e.Pos == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("word %d %q: %s", e.Pos, e.Word, e.Reason)
+}
+
+// Unwrap returns ErrInvalidWords.
+func (e *WordsError) Unwrap() error {
+	return ErrInvalidWords
+}
+
+type wordKind int
+
+const (
+	wordOne wordKind = iota
+	wordTen
+	wordHundred
+	wordScale
+	wordMinus
+	wordPutin
+	// wordDenominator is the last word of a denominator, such as júzden
+	// in "on júzden" or mıńnan.
+	wordDenominator
+)
+
+type word struct {
+	kind  wordKind
+	value int
+}
+
+// vocabulary holds the words of one script, taken from the same tables
+// ToWord spells numbers with.
+type vocabulary struct {
+	name   string
+	isCyr  bool
+	zero   string
+	one    string
+	putin  string
+	scales []string
+	words  map[string]word
+	// denominators maps whole denominators, such as "on mıńnan", to the
+	// number of fractional digits they stand for.
+	denominators map[string]int
+}
+
+var (
+	vocabularyLat = newVocabulary("Latin", false)
+	vocabularyCyr = newVocabulary("Cyrillic", true)
+)
+
+func newVocabulary(name string, isCyr bool) *vocabulary {
+	ones, tens, thousands := onesLat, tensLat, thousandsLat
+	hundred, minus, putin := hundredLat, minusLat, putinLat
+	if isCyr {
+		ones, tens, thousands = onesCyr, tensCyr, thousandsCyr
+		hundred, minus, putin = hundredCyr, minusCyr, putinCyr
+	}
+
+	v := &vocabulary{
+		name:         name,
+		isCyr:        isCyr,
+		zero:         ones[0],
+		one:          ones[1],
+		putin:        putin,
+		scales:       thousands,
+		words:        map[string]word{},
+		denominators: map[string]int{},
+	}
+	for i, w := range ones {
+		v.words[w] = word{wordOne, i}
+	}
+	for i, w := range tens {
+		v.words[w] = word{wordTen, (i + 1) * 10}
+	}
+	for i, w := range thousands[1:] {
+		v.words[w] = word{wordScale, i + 1}
+	}
+	v.words[hundred] = word{kind: wordHundred}
+	v.words[minus] = word{kind: wordMinus}
+	v.words[putin] = word{kind: wordPutin}
+	for power := 1; power <= maxFractionDigits; power++ {
+		denom := getDenominator(power, isCyr)
+		v.denominators[denom] = power
+		last := denom[strings.LastIndexByte(denom, ' ')+1:]
+		v.words[last] = word{kind: wordDenominator}
+	}
+	return v
+}
+
+// FromWords reads a number spelled out in Karakalpak words, in either
+// script, and returns it as a decimal string: "bir júz jigirma úsh" is
+// "123" and "minus on eki pútin júzden jetpis bes" is "-12.75". It reads
+// everything ToWord writes, and also "bir júz" and "bir mıń" for júz and
+// mıń. Errors are *WordsError values naming the word at fault.
+func FromWords(text string) (string, error) {
+	words := strings.Fields(strutil.Lower(text))
+	if len(words) == 0 {
+		return "", &WordsError{Reason: "no words"}
+	}
+
+	var v *vocabulary
+	switch {
+	case vocabularyLat.has(words[0]):
+		v = vocabularyLat
+	case vocabularyCyr.has(words[0]):
+		v = vocabularyCyr
+	default:
+		return "", &WordsError{Pos: 1, Word: words[0], Reason: "not a number word"}
+	}
+	for i, w := range words {
+		if v.has(w) {
+			continue
+		}
+		reason := "not a number word"
+		if other := otherVocabulary(v); other.has(w) {
+			reason = fmt.Sprintf("is %s, but the number started in %s", other.name, v.name)
+		}
+		return "", &WordsError{Pos: i + 1, Word: w, Reason: reason}
+	}
+
+	p := &wordsParser{v: v, words: words}
+	negative := p.next(wordMinus)
+	if negative && p.atEnd() {
+		return "", p.errorAt(0, "must be followed by a number")
+	}
+
+	whole, err := p.integer("a number")
+	if err != nil {
+		return "", err
+	}
+	result := whole
+	if p.next(wordPutin) {
+		frac, err := p.fraction()
+		if err != nil {
+			return "", err
+		}
+		result += "." + frac
+	}
+	if !p.atEnd() {
+		return "", p.unexpected()
+	}
+
+	if negative && strings.Trim(result, "0.") != "" {
+		result = "-" + result
+	}
+	return result, nil
+}
+
+func (v *vocabulary) has(w string) bool {
+	_, ok := v.words[w]
+	return ok
+}
+
+func otherVocabulary(v *vocabulary) *vocabulary {
+	if v == vocabularyLat {
+		return vocabularyCyr
+	}
+	return vocabularyLat
+}
+
+// wordsParser reads a number from words, all of which are in v.
+type wordsParser struct {
+	v     *vocabulary
+	words []string
+	pos   int
+}
+
+func (p *wordsParser) atEnd() bool {
+	return p.pos >= len(p.words)
+}
+
+func (p *wordsParser) peek() word {
+	return p.v.words[p.words[p.pos]]
+}
+
+// next consumes the next word if it is of the given kind.
+func (p *wordsParser) next(kind wordKind) bool {
+	if !p.atEnd() && p.peek().kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorAt reports a problem with the word at index i.
+func (p *wordsParser) errorAt(i int, format string, args ...interface{}) error {
+	return &WordsError{Pos: i + 1, Word: p.words[i], Reason: fmt.Sprintf(format, args...)}
+}
+
+// unexpected reports the next word as out of place.
+func (p *wordsParser) unexpected() error {
+	switch p.peek().kind {
+	case wordMinus:
+		return p.errorAt(p.pos, "must come first")
+	case wordPutin:
+		return p.errorAt(p.pos, "a number has only one %s", p.words[p.pos])
+	case wordDenominator:
+		return p.errorAt(p.pos, "is a denominator and belongs after %s", p.v.putin)
+	}
+	return p.errorAt(p.pos, "cannot follow %q", p.words[p.pos-1])
+}
+
+// integer reads a whole number up to pútin or the end of the words and
+// returns its digits. what names the number in the error for a missing
+// one.
+func (p *wordsParser) integer(what string) (string, error) {
+	if p.atEnd() || p.peek().kind == wordPutin {
+		if p.atEnd() {
+			return "", &WordsError{Reason: fmt.Sprintf("missing %s after %q", what, p.words[p.pos-1])}
+		}
+		return "", p.errorAt(p.pos, "needs a whole number before it, as in %s %s", p.v.zero, p.words[p.pos])
+	}
+
+	if w := p.peek(); w.kind == wordOne && w.value == 0 {
+		p.pos++
+		if !p.atEnd() && p.peek().kind != wordPutin {
+			return "", p.errorAt(p.pos, "cannot follow %q", p.words[p.pos-1])
+		}
+		return "0", nil
+	}
+
+	// groups holds the value of each group of three digits by its scale:
+	// 0 for units, 1 for mıń, 2 for million and so on.
+	groups := map[int]int{}
+	top, last := -1, len(thousandsLat)
+	var (
+		cur                        int
+		hasHundred, hasTen, hasOne bool
+	)
+
+	for ; !p.atEnd(); p.pos++ {
+		w := p.peek()
+		switch w.kind {
+		case wordOne:
+			if w.value == 0 {
+				return "", p.errorAt(p.pos, "cannot be part of a larger number")
+			}
+			if hasOne {
+				return "", p.errorAt(p.pos, "cannot follow %q", p.words[p.pos-1])
+			}
+			cur += w.value
+			hasOne = true
+		case wordTen:
+			if hasTen || hasOne {
+				return "", p.errorAt(p.pos, "cannot follow %q", p.words[p.pos-1])
+			}
+			cur += w.value
+			hasTen = true
+		case wordHundred:
+			if hasHundred || hasTen {
+				return "", p.errorAt(p.pos, "cannot follow %q", p.words[p.pos-1])
+			}
+			if !hasOne {
+				cur = 1
+			}
+			cur *= 100
+			hasHundred, hasOne = true, false
+		case wordScale:
+			if w.value >= last {
+				return "", p.errorAt(p.pos, "cannot follow %q; larger scales come first", p.v.scales[last])
+			}
+			if cur == 0 {
+				if w.value != 1 {
+					return "", p.errorAt(p.pos, "needs a count before it, as in %s %s", p.v.one, p.words[p.pos])
+				}
+				cur = 1
+			}
+			groups[w.value] = cur
+			if top < 0 {
+				top = w.value
+			}
+			last = w.value
+			cur, hasHundred, hasTen, hasOne = 0, false, false, false
+		case wordPutin:
+			return formatGroups(groups, cur, top), nil
+		default:
+			return "", p.unexpected()
+		}
+	}
+	return formatGroups(groups, cur, top), nil
+}
+
+// fraction reads the denominator and numerator that follow pútin and
+// returns the fractional digits.
+func (p *wordsParser) fraction() (string, error) {
+	start := p.pos
+	end := start
+	for end < len(p.words) && p.v.words[p.words[end]].kind != wordDenominator {
+		end++
+	}
+	if end == len(p.words) {
+		return "", p.errorAt(start-1, "must be followed by a denominator such as %s", getDenominator(2, p.v.isCyr))
+	}
+	denom := strings.Join(p.words[start:end+1], " ")
+	power, ok := p.v.denominators[denom]
+	if !ok {
+		return "", p.errorAt(end, "%q is not a denominator", denom)
+	}
+	p.pos = end + 1
+
+	numerator, err := p.integer("the numerator")
+	if err != nil {
+		return "", err
+	}
+	if len(numerator) > power {
+		return "", &WordsError{Pos: end + 1, Word: p.words[end], Reason: fmt.Sprintf("numerator %s is too large for %q", numerator, denom)}
+	}
+	return strings.Repeat("0", power-len(numerator)) + numerator, nil
+}
+
+// formatGroups returns the digits of a number from its groups of three
+// digits, the last of which, cur, has not been stored yet.
+func formatGroups(groups map[int]int, cur, top int) string {
+	if top < 0 {
+		return fmt.Sprint(cur)
+	}
+	groups[0] += cur
+	var b strings.Builder
+	fmt.Fprint(&b, groups[top])
+	for i := top - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%03d", groups[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package number
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestFromWords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"nol", "0"},
+		{"bir júz jigirma úsh", "123"},
+		{"júz", "100"},
+		{"bir júz", "100"},
+		{"mıń", "1000"},
+		{"mıń bir júz", "1100"},
+		{"bir júz mıń", "100000"},
+		{"bir million mıń", "1001000"},
+		{"on eki pútin júzden jetpis bes", "12.75"},
+		{"on pútin júzden bes", "10.05"},
+		{"minus bes", "-5"},
+		{"minus nol pútin onnan bes", "-0.5"},
+		{"Bir Júz  Jigirma", "120"},
+		{"бир жүз жигирма үш", "123"},
+		{"он еки пүтін жүзден жетпис бес", "12.75"},
+		{"минус мың", "-1000"},
+		{"eki nonillion", "2000000000000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := FromWords(tt.input)
+			if err != nil {
+				t.Fatalf("FromWords(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("FromWords(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromWordsErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "no words"},
+		{"bir dos", `word 2 "dos": not a number word`},
+		{"bir бес", `word 2 "бес": is Cyrillic, but the number started in Latin`},
+		{"bir eki", `word 2 "eki": cannot follow "bir"`},
+		{"jigirma júz", `word 2 "júz": cannot follow "jigirma"`},
+		{"bes nol", `word 2 "nol": cannot be part of a larger number`},
+		{"nol bes", `word 2 "bes": cannot follow "nol"`},
+		{"million", `word 1 "million": needs a count before it, as in bir million`},
+		{"mıń million", `word 2 "million": cannot follow "mıń"; larger scales come first`},
+		{"bir minus", `word 2 "minus": must come first`},
+		{"minus", `word 1 "minus": must be followed by a number`},
+		{"pútin onnan bes", `word 1 "pútin": needs a whole number before it, as in nol pútin`},
+		{"bir pútin", `word 2 "pútin": must be followed by a denominator such as júzden`},
+		{"bir pútin on júzden bes", `word 4 "júzden": "on júzden" is not a denominator`},
+		{"bir pútin onnan", `missing the numerator after "onnan"`},
+		{"bir pútin onnan on bes", `word 3 "onnan": numerator 15 is too large for "onnan"`},
+		{"bir pútin onnan bes pútin", `word 5 "pútin": a number has only one pútin`},
+		{"bir onnan", `word 2 "onnan": is a denominator and belongs after pútin`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := FromWords(tt.input)
+			if !errors.Is(err, ErrInvalidWords) {
+				t.Fatalf("FromWords(%q): err = %v, want ErrInvalidWords", tt.input, err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("FromWords(%q): err = %q, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromWordsRoundTrip(t *testing.T) {
+	for _, n := range []float64{0, 7, 10, 19, 100, 101, 999, 1000, 1001, 12345, 100000, 1000001, 987654321, 1e12 + 15} {
+		for _, script := range []string{"lat", "cyr"} {
+			words, err := ToWord(n, script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := FromWords(words)
+			if err != nil {
+				t.Fatalf("FromWords(%q) returned error: %v", words, err)
+			}
+			if want := strconv.FormatFloat(n, 'f', -1, 64); got != want {
+				t.Errorf("FromWords(%q) = %q, want %q", words, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package office
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/markup"
+)
+
+const epubMimetype = "application/epub+zip"
+
+// ncxText is the text of NCX navigation labels, titles and authors.
+var ncxText = []string{"text"}
+
+// opfText lists the OPF metadata elements that are converted.
+var opfText = []string{"dc:title", "dc:creator"}
+
+var dcLanguage = regexp.MustCompile(`(<dc:language\b[^>]*>)([^<]*)(</dc:language>)`)
+
+type container struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Items []struct {
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+}
+
+// ConvertEPUB applies convert to an EPUB: the XHTML content documents
+// listed in the OPF manifest are converted with html, and so are the NCX
+// table of contents and the dc:title and dc:creator metadata. dc:language
+// and language attributes are set to html.Lang. The mimetype entry is
+// written first and stored uncompressed, as the OCF container requires.
+func ConvertEPUB(src string, convert func(string) string, html markup.Options) (string, error) {
+	zr, err := zip.NewReader(strings.NewReader(src), int64(len(src)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotDocument, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	opfPath, err := rootfile(files)
+	if err != nil {
+		return "", err
+	}
+	opfData, err := readFile(files[opfPath])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", opfPath, err)
+	}
+	var pkg opfPackage
+	if err := xml.Unmarshal([]byte(opfData), &pkg); err != nil {
+		return "", fmt.Errorf("%w: %s: %s", ErrNotDocument, opfPath, err)
+	}
+
+	html.XML = false
+	ncx := markup.Options{XML: true, Only: ncxText, Lang: html.Lang}
+	parts := map[string]func(string) string{
+		opfPath: func(s string) string { return convertOPF(s, convert, html.Lang) },
+	}
+	for _, item := range pkg.Items {
+		href, err := url.PathUnescape(strings.SplitN(item.Href, "#", 2)[0])
+		if err != nil {
+			href = item.Href
+		}
+		name := path.Join(path.Dir(opfPath), href)
+		switch item.MediaType {
+		case "application/xhtml+xml":
+			parts[name] = func(s string) string { return markup.Convert(s, convert, html) }
+		case "application/x-dtbncx+xml":
+			parts[name] = func(s string) string { return markup.Convert(s, convert, ncx) }
+		}
+	}
+
+	var b strings.Builder
+	zw := zip.NewWriter(&b)
+	zw.SetComment(zr.Comment)
+	mimetype := epubMimetype
+	if f := files["mimetype"]; f != nil {
+		if mimetype, err = readFile(f); err != nil {
+			return "", fmt.Errorf("mimetype: %w", err)
+		}
+	}
+	if err := writeStored(zw, "mimetype", mimetype); err != nil {
+		return "", err
+	}
+
+	for _, f := range zr.File {
+		if f.Name == "mimetype" {
+			continue
+		}
+		conv, ok := parts[f.Name]
+		if !ok {
+			if err := zw.Copy(f); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		data, err := readFile(f)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Name, err)
+		}
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.WriteString(w, conv(data)); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// rootfile returns the path of the OPF package document named in
+// META-INF/container.xml.
+func rootfile(files map[string]*zip.File) (string, error) {
+	f := files["META-INF/container.xml"]
+	if f == nil {
+		return "", fmt.Errorf("%w: META-INF/container.xml is missing", ErrNotDocument)
+	}
+	data, err := readFile(f)
+	if err != nil {
+		return "", fmt.Errorf("META-INF/container.xml: %w", err)
+	}
+	var c container
+	if err := xml.Unmarshal([]byte(data), &c); err != nil {
+		return "", fmt.Errorf("%w: META-INF/container.xml: %s", ErrNotDocument, err)
+	}
+	for _, r := range c.Rootfiles {
+		if r.MediaType == "application/oebps-package+xml" || r.MediaType == "" {
+			if files[r.FullPath] == nil {
+				return "", fmt.Errorf("%w: %s is missing", ErrNotDocument, r.FullPath)
+			}
+			return r.FullPath, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no package document in META-INF/container.xml", ErrNotDocument)
+}
+
+// convertOPF converts the title and creator of an OPF package document
+// and sets the script of its Karakalpak dc:language.
+func convertOPF(src string, convert func(string) string, lang string) string {
+	out := markup.Convert(src, convert, markup.Options{XML: true, Only: opfText, Lang: lang})
+	if lang == "" {
+		return out
+	}
+	return dcLanguage.ReplaceAllStringFunc(out, func(el string) string {
+		m := dcLanguage.FindStringSubmatch(el)
+		value := strings.TrimSpace(m[2])
+		if tag, ok := markup.LangTag(value, lang); ok {
+			value = tag
+		} else if strings.EqualFold(value, "kaa") {
+			value += "-" + lang
+		} else {
+			return el
+		}
+		return m[1] + value + m[3]
+	})
+}
+
+// writeStored writes an uncompressed entry without a data descriptor or
+// extra fields, as the EPUB mimetype entry must be.
+func writeStored(zw *zip.Writer, name, data string) error {
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(data)),
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(data)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, data)
+	return err
+}
@@ -0,0 +1,116 @@
+// Package office converts the text of DOCX, ODT and EPUB documents. The
+// ZIP container is rewritten entry by entry: the parts that hold document
+// text are converted and every other entry, styles included, is copied
+// without being recompressed.
+package office
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotDocument is returned when the input is not a ZIP container with
+// the expected document part.
+var ErrNotDocument = errors.New("not a document")
+
+var docxRules = textRules{
+	paragraphs:    set("w:p"),
+	texts:         set("w:t"),
+	breaks:        set("w:tab", "w:br", "w:cr", "w:ptab", "w:noBreakHyphen", "w:softHyphen", "w:sym", "w:drawing", "w:footnoteReference", "w:endnoteReference", "w:commentReference"),
+	preserveSpace: true,
+}
+
+var odtRules = textRules{
+	paragraphs: set("text:p", "text:h"),
+	texts:      set("text:p", "text:h", "text:span", "text:a", "text:ruby-base"),
+	breaks:     set("text:s", "text:tab", "text:line-break", "text:soft-page-break", "text:note", "draw:frame"),
+}
+
+// ConvertDOCX applies convert to the text of the body, headers, footers,
+// footnotes, endnotes and comments of a DOCX document.
+func ConvertDOCX(src string, convert func(string) string) (string, error) {
+	return convertZip(src, "word/document.xml", func(name string) (textRules, bool) {
+		dir, file := path.Split(name)
+		if dir != "word/" || path.Ext(file) != ".xml" {
+			return textRules{}, false
+		}
+		base := strings.TrimSuffix(file, ".xml")
+		for _, part := range []string{"document", "header", "footer", "footnotes", "endnotes", "comments"} {
+			if base == part || (strings.HasPrefix(base, part) && strings.Trim(base[len(part):], "0123456789") == "") {
+				return docxRules, true
+			}
+		}
+		return textRules{}, false
+	}, convert)
+}
+
+// ConvertODT applies convert to the text of an ODT document: content.xml
+// for the body, notes and comments, and styles.xml for headers and
+// footers.
+func ConvertODT(src string, convert func(string) string) (string, error) {
+	return convertZip(src, "content.xml", func(name string) (textRules, bool) {
+		return odtRules, name == "content.xml" || name == "styles.xml"
+	}, convert)
+}
+
+// convertZip rewrites the ZIP container in src, converting the parts for
+// which parts reports true. required must be present.
+func convertZip(src, required string, parts func(string) (textRules, bool), convert func(string) string) (string, error) {
+	zr, err := zip.NewReader(strings.NewReader(src), int64(len(src)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotDocument, err)
+	}
+	found := false
+	for _, f := range zr.File {
+		if f.Name == required {
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("%w: %s is missing", ErrNotDocument, required)
+	}
+
+	var b strings.Builder
+	zw := zip.NewWriter(&b)
+	zw.SetComment(zr.Comment)
+	for _, f := range zr.File {
+		rules, ok := parts(f.Name)
+		if !ok {
+			if err := zw.Copy(f); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		data, err := readFile(f)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Name, err)
+		}
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.WriteString(w, convertXML(data, convert, rules)); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func readFile(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return string(data), err
+}
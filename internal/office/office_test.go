@@ -0,0 +1,220 @@
+package office
+
+import (
+	"archive/zip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dontbeidle/kaalin/internal/converter"
+	"github.com/dontbeidle/kaalin/internal/markup"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// makeZip builds a ZIP container from name/content pairs, storing the
+// first entry uncompressed.
+func makeZip(t *testing.T, files ...string) string {
+	t.Helper()
+	var b strings.Builder
+	zw := zip.NewWriter(&b)
+	for i := 0; i+1 < len(files); i += 2 {
+		method := zip.Deflate
+		if i == 0 {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: files[i], Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// readZip returns the names and contents of the entries of a container.
+func readZip(t *testing.T, data string) ([]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.NewReader(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		s, err := readFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = s
+	}
+	return names, files
+}
+
+func TestConvertDOCX(t *testing.T) {
+	const styles = `<w:styles><w:style w:styleId="a"><w:name w:val="shahar"/></w:style></w:styles>`
+	doc := makeZip(t,
+		"[Content_Types].xml", `<Types/>`,
+		"word/document.xml", `<w:document><w:body><w:p><w:r><w:rPr><w:b/></w:rPr><w:t>S</w:t></w:r><w:r><w:t>ahar &amp; </w:t></w:r><w:r><w:instrText>PAGE</w:instrText></w:r><w:r><w:tab/><w:t xml:space="preserve">qala</w:t></w:r></w:p></w:body></w:document>`,
+		"word/header1.xml", `<w:hdr><w:p><w:r><w:t>bas</w:t></w:r></w:p></w:hdr>`,
+		"word/footnotes.xml", `<w:footnotes><w:p><w:r><w:t>eskertiw</w:t></w:r></w:p></w:footnotes>`,
+		"word/styles.xml", styles,
+	)
+
+	out, err := ConvertDOCX(doc, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, out)
+	if want := []string{"[Content_Types].xml", "word/document.xml", "word/header1.xml", "word/footnotes.xml", "word/styles.xml"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %q, want %q", names, want)
+	}
+	wantDoc := `<w:document><w:body><w:p><w:r><w:rPr><w:b/></w:rPr><w:t>S</w:t></w:r><w:r><w:t>AHAR &amp; </w:t></w:r><w:r><w:instrText>PAGE</w:instrText></w:r><w:r><w:tab/><w:t xml:space="preserve">QALA</w:t></w:r></w:p></w:body></w:document>`
+	if files["word/document.xml"] != wantDoc {
+		t.Errorf("document.xml =\n%s\nwant\n%s", files["word/document.xml"], wantDoc)
+	}
+	if !strings.Contains(files["word/header1.xml"], "BAS") || !strings.Contains(files["word/footnotes.xml"], "ESKERTIW") {
+		t.Errorf("header or footnotes not converted: %q %q", files["word/header1.xml"], files["word/footnotes.xml"])
+	}
+	if files["word/styles.xml"] != styles {
+		t.Errorf("styles.xml changed: %q", files["word/styles.xml"])
+	}
+}
+
+func TestConvertDOCXSplitDigraph(t *testing.T) {
+	doc := makeZip(t, "word/document.xml",
+		`<w:p><w:r><w:rPr><w:i/></w:rPr><w:t>S</w:t></w:r><w:r><w:t>hahar</w:t></w:r><w:r><w:t xml:space="preserve"> C</w:t></w:r><w:r><w:t>h</w:t></w:r></w:p>`)
+
+	out, err := ConvertDOCX(doc, converter.Latin2Cyrillic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, files := readZip(t, out)
+	want := `<w:p><w:r><w:rPr><w:i/></w:rPr><w:t>Ш</w:t></w:r><w:r><w:t>аҳар</w:t></w:r><w:r><w:t xml:space="preserve"> Ч</w:t></w:r><w:r><w:t></w:t></w:r></w:p>`
+	if files["word/document.xml"] != want {
+		t.Errorf("document.xml =\n%s\nwant\n%s", files["word/document.xml"], want)
+	}
+}
+
+func TestConvertODT(t *testing.T) {
+	const meta = `<office:document-meta><dc:title>shahar</dc:title></office:document-meta>`
+	doc := makeZip(t,
+		"mimetype", "application/vnd.oasis.opendocument.text",
+		"content.xml", `<office:text><text:h>bas</text:h><text:p>s<text:span text:style-name="T1">oz</text:span><text:s/>men<text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>note</text:p></text:note-body></text:note></text:p></office:text>`,
+		"styles.xml", `<office:master-styles><style:header><text:p>header</text:p></style:header></office:master-styles>`,
+		"meta.xml", meta,
+	)
+
+	out, err := ConvertODT(doc, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, out)
+	if names[0] != "mimetype" {
+		t.Errorf("first entry = %q, want mimetype", names[0])
+	}
+	zr, _ := zip.NewReader(strings.NewReader(out), int64(len(out)))
+	if zr.File[0].Method != zip.Store {
+		t.Error("mimetype is compressed")
+	}
+	wantContent := `<office:text><text:h>BAS</text:h><text:p>S<text:span text:style-name="T1">OZ</text:span><text:s/>MEN<text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>NOTE</text:p></text:note-body></text:note></text:p></office:text>`
+	if files["content.xml"] != wantContent {
+		t.Errorf("content.xml =\n%s\nwant\n%s", files["content.xml"], wantContent)
+	}
+	if !strings.Contains(files["styles.xml"], "HEADER") {
+		t.Errorf("styles.xml header not converted: %q", files["styles.xml"])
+	}
+	if files["meta.xml"] != meta {
+		t.Errorf("meta.xml changed: %q", files["meta.xml"])
+	}
+}
+
+func TestConvertNotDocument(t *testing.T) {
+	if _, err := ConvertDOCX("plain text", upper); !errors.Is(err, ErrNotDocument) {
+		t.Errorf("ConvertDOCX(text): err = %v, want ErrNotDocument", err)
+	}
+	if _, err := ConvertODT(makeZip(t, "other.xml", "<a/>"), upper); !errors.Is(err, ErrNotDocument) {
+		t.Errorf("ConvertODT without content.xml: err = %v, want ErrNotDocument", err)
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  []string
+	}{
+		{[]string{"S", "hahar"}, []string{"Ш", "аҳар"}},
+		{[]string{"sa", "lem"}, []string{"са", "лем"}},
+		{[]string{"s", "h", "a"}, []string{"ш", "", "а"}},
+		{[]string{"", "c", "h"}, []string{"", "ч", ""}},
+	}
+	for _, tt := range tests {
+		if got := distribute(tt.parts, converter.Latin2Cyrillic); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("distribute(%q) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestConvertEPUB(t *testing.T) {
+	const css = "p { font-family: shahar; }"
+	book := makeZip(t,
+		"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+		"mimetype", "application/epub+zip",
+		"OEBPS/content.opf", `<package><metadata><dc:title>kitap</dc:title><dc:creator>avtor</dc:creator><dc:publisher>baspa</dc:publisher><dc:language>kaa-Latn</dc:language></metadata>`+
+			`<manifest><item id="c1" href="Text/ch%201.xhtml" media-type="application/xhtml+xml"/><item id="toc" href="toc.ncx" media-type="application/x-dtbncx+xml"/><item id="css" href="style.css" media-type="text/css"/></manifest></package>`,
+		"OEBPS/Text/ch 1.xhtml", `<html xml:lang="kaa-Latn"><head><style>p{}</style></head><body><p title="bas">text</p></body></html>`,
+		"OEBPS/toc.ncx", `<ncx><docTitle><text>kitap</text></docTitle><navMap><navPoint id="n1"><navLabel><text>bap</text></navLabel><content src="Text/ch%201.xhtml"/></navPoint></navMap></ncx>`,
+		"OEBPS/style.css", css,
+	)
+
+	out, err := ConvertEPUB(book, upper, markup.Options{Attrs: []string{"title"}, Lang: "Cyrl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The OCF container requires the mimetype entry to come first, stored
+	// and without extra fields, so that its bytes sit at offset 30.
+	if got := out[30:58]; got != "mimetypeapplication/epub+zip" {
+		t.Errorf("first local entry = %q", got)
+	}
+	zr, err := zip.NewReader(strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Method != zip.Store || zr.File[0].Flags&0x8 != 0 {
+		t.Errorf("mimetype: method %d, flags %#x", zr.File[0].Method, zr.File[0].Flags)
+	}
+
+	names, files := readZip(t, out)
+	if len(names) != 6 || names[1] != "META-INF/container.xml" {
+		t.Errorf("entries = %q", names)
+	}
+	want := map[string]string{
+		"OEBPS/content.opf":     `<package><metadata><dc:title>KITAP</dc:title><dc:creator>AVTOR</dc:creator><dc:publisher>baspa</dc:publisher><dc:language>kaa-Cyrl</dc:language></metadata>`,
+		"OEBPS/Text/ch 1.xhtml": `<html xml:lang="kaa-Cyrl"><head><style>p{}</style></head><body><p title="BAS">TEXT</p></body></html>`,
+		"OEBPS/toc.ncx":         `<ncx><docTitle><text>KITAP</text></docTitle><navMap><navPoint id="n1"><navLabel><text>BAP</text></navLabel>`,
+		"OEBPS/style.css":       css,
+	}
+	for name, prefix := range want {
+		if !strings.HasPrefix(files[name], prefix) {
+			t.Errorf("%s =\n%s\nwant prefix\n%s", name, files[name], prefix)
+		}
+	}
+}
+
+func TestConvertEPUBNotBook(t *testing.T) {
+	if _, err := ConvertEPUB(makeZip(t, "mimetype", "application/epub+zip"), upper, markup.Options{}); !errors.Is(err, ErrNotDocument) {
+		t.Errorf("err = %v, want ErrNotDocument", err)
+	}
+}
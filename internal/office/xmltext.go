@@ -0,0 +1,258 @@
+package office
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// textRules describes where the text of a word-processing XML part is.
+type textRules struct {
+	// paragraphs are the elements whose text is converted as a whole, so
+	// that a digraph split between runs still converts correctly.
+	paragraphs map[string]bool
+	// texts are the elements whose character data is text.
+	texts map[string]bool
+	// breaks are elements, such as tabs and line breaks, that separate
+	// the text of a paragraph.
+	breaks map[string]bool
+	// preserveSpace adds xml:space="preserve" to text elements that gain
+	// leading or trailing spaces.
+	preserveSpace bool
+}
+
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// segment is the character data of a text element.
+type segment struct {
+	start, end int // content offsets in the source
+	tagEnd     int // offset of the '>' or "/>" ending the start tag
+	tag        string
+	group      int
+}
+
+type openElement struct {
+	name           string
+	tagEnd         int
+	tagText        string
+	paragraphGroup int // -1 unless a paragraph
+}
+
+// convertXML converts the text of an XML part paragraph by paragraph and
+// leaves all markup unchanged.
+func convertXML(src string, convert func(string) string, rules textRules) string {
+	var (
+		stack    []openElement
+		segments []segment
+		groups   int
+		paras    []int // indexes into stack of open paragraphs
+	)
+	newGroup := func() int {
+		groups++
+		return groups - 1
+	}
+
+	for i := 0; i < len(src); {
+		lt := strings.IndexByte(src[i:], '<')
+		if lt < 0 {
+			lt = len(src) - i
+		}
+		if lt > 0 && len(stack) > 0 && rules.texts[stack[len(stack)-1].name] {
+			top := stack[len(stack)-1]
+			var group int
+			if len(paras) > 0 {
+				group = stack[paras[len(paras)-1]].paragraphGroup
+			} else {
+				group = newGroup()
+			}
+			segments = append(segments, segment{start: i, end: i + lt, tagEnd: top.tagEnd, tag: top.tagText, group: group})
+		}
+		i += lt
+		if i >= len(src) {
+			break
+		}
+
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			i += until(rest, "-->")
+		case strings.HasPrefix(rest, "<![CDATA["):
+			i += until(rest, "]]>")
+		case strings.HasPrefix(rest, "<?"), strings.HasPrefix(rest, "<!"):
+			i += until(rest, ">")
+		case strings.HasPrefix(rest, "</"):
+			n := until(rest, ">")
+			name := strings.TrimSpace(strings.TrimSuffix(rest[2:n], ">"))
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].name == name {
+					stack = stack[:j]
+					break
+				}
+			}
+			for len(paras) > 0 && paras[len(paras)-1] >= len(stack) {
+				paras = paras[:len(paras)-1]
+			}
+			i += n
+		default:
+			n := tagLen(rest)
+			tag := rest[:n]
+			name := tagName(tag)
+			selfClosing := strings.HasSuffix(tag, "/>")
+			end := i + n - 1
+			if selfClosing {
+				end--
+			}
+
+			if rules.breaks[name] && len(paras) > 0 {
+				stack[paras[len(paras)-1]].paragraphGroup = newGroup()
+			}
+			if !selfClosing {
+				el := openElement{name: name, tagEnd: end, tagText: tag, paragraphGroup: -1}
+				if rules.paragraphs[name] {
+					el.paragraphGroup = newGroup()
+					paras = append(paras, len(stack))
+				}
+				stack = append(stack, el)
+			}
+			i += n
+		}
+	}
+
+	return applySegments(src, segments, groups, convert, rules)
+}
+
+// applySegments converts the segments group by group and rewrites them.
+func applySegments(src string, segments []segment, groups int, convert func(string) string, rules textRules) string {
+	byGroup := make([][]int, groups)
+	for i, s := range segments {
+		byGroup[s.group] = append(byGroup[s.group], i)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, idx := range byGroup {
+		if len(idx) == 0 {
+			continue
+		}
+		parts := make([]string, len(idx))
+		for k, i := range idx {
+			parts[k] = html.UnescapeString(src[segments[i].start:segments[i].end])
+		}
+		for k, text := range distribute(parts, convert) {
+			if text == parts[k] {
+				continue
+			}
+			s := segments[idx[k]]
+			edits = append(edits, edit{s.start, s.end, escapeText(text)})
+			if rules.preserveSpace && hasEdgeSpace(text) && !hasEdgeSpace(parts[k]) && !strings.Contains(s.tag, "xml:space") {
+				edits = append(edits, edit{s.tagEnd, s.tagEnd, ` xml:space="preserve"`})
+			}
+		}
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// distribute converts the concatenation of parts and splits the result
+// back into as many parts. Each boundary stays where converting the text
+// before it gives a prefix of the whole result; otherwise it moves right
+// until it does, so a digraph split between parts goes to the first.
+func distribute(parts []string, convert func(string) string) []string {
+	joined := strings.Join(parts, "")
+	full := convert(joined)
+	out := make([]string, len(parts))
+
+	pos, bound, cum := 0, 0, 0
+	for i := 0; i < len(parts)-1; i++ {
+		cum += len(parts[i])
+		end := max(cum, bound)
+		for {
+			c := convert(joined[:end])
+			if len(c) >= pos && strings.HasPrefix(full, c) {
+				out[i] = full[pos:len(c)]
+				pos = len(c)
+				break
+			}
+			if end >= len(joined) {
+				out[i] = full[pos:]
+				pos = len(full)
+				break
+			}
+			_, size := utf8.DecodeRuneInString(joined[end:])
+			end += size
+		}
+		bound = end
+	}
+	out[len(parts)-1] = full[pos:]
+	return out
+}
+
+func hasEdgeSpace(s string) bool {
+	if s == "" {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(s)
+	last, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsSpace(first) || unicode.IsSpace(last)
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// tagLen returns the length of the tag at the start of s, skipping '>'
+// inside quoted attribute values.
+func tagLen(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func tagName(tag string) string {
+	end := 1
+	for end < len(tag) && !strings.ContainsRune(" \t\r\n/>", rune(tag[end])) {
+		end++
+	}
+	return tag[1:end]
+}
+
+func until(s, end string) int {
+	i := strings.Index(s, end)
+	if i < 0 {
+		return len(s)
+	}
+	return i + len(end)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -55,6 +56,15 @@ func Error(problem, help string) {
 	}
 }
 
+// Note prints an informational message to stderr.
+func Note(msg string) {
+	if Quiet {
+		return
+	}
+	yellow := color.New(color.FgYellow).SprintFunc()
+	fmt.Fprintf(os.Stderr, "%s %s\n", yellow("•"), msg)
+}
+
 // Result prints a result to stdout.
 func Result(text string) {
 	fmt.Print(text)
@@ -65,6 +75,32 @@ func ResultLn(text string) {
 	fmt.Println(text)
 }
 
+// Diff prints a unified diff to stdout, coloring removed and added lines.
+func Diff(text string) {
+	bold := color.New(color.Bold).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		body := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			body = bold(body)
+		case strings.HasPrefix(line, "@@"):
+			body = cyan(body)
+		case strings.HasPrefix(line, "-"):
+			body = red(body)
+		case strings.HasPrefix(line, "+"):
+			body = green(body)
+		}
+		fmt.Println(body)
+	}
+}
+
 func printJSON(v interface{}) {
 	enc := json.NewEncoder(os.Stderr)
 	enc.SetEscapeHTML(false)
@@ -0,0 +1,94 @@
+// Package placeholder keeps interpolation placeholders such as {name},
+// %s and {{.Count}} out of script conversion.
+package placeholder
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pattern matches Go templates ({{.Count}}), brace placeholders ({name},
+// {0}) and printf verbs (%s, %d, %1$s, %(name)s, %.2f, %%).
+var pattern = regexp.MustCompile(`\{\{.*?\}\}|\{[^{}\s]*\}|%(\([^)]*\))?(\d+\$)?[-+#0]*(\d+|\*)?(\.(\d+|\*))?[bcdeEfFgGiosuxXqvTpn%]`)
+
+// find returns the locations of the placeholders of s. A bare verb such
+// as %e directly followed by a letter is a percent sign inside a word,
+// as in 100%ese, and is left out.
+func find(s string) [][]int {
+	locs := pattern.FindAllStringIndex(s, -1)
+	kept := locs[:0]
+	for _, loc := range locs {
+		if loc[1]-loc[0] == 2 && s[loc[0]] == '%' && s[loc[0]+1] != '%' {
+			if r, _ := utf8.DecodeRuneInString(s[loc[1]:]); unicode.IsLetter(r) {
+				continue
+			}
+		}
+		kept = append(kept, loc)
+	}
+	return kept
+}
+
+// Convert applies convert to the text between the placeholders of s and
+// copies the placeholders unchanged.
+func Convert(s string, convert func(string) string) string {
+	locs := find(s)
+	if len(locs) == 0 {
+		return convert(s)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		b.WriteString(convert(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(convert(s[last:]))
+	return b.String()
+}
+
+// Find returns the placeholders of s in order.
+func Find(s string) []string {
+	var out []string
+	for _, loc := range find(s) {
+		out = append(out, s[loc[0]:loc[1]])
+	}
+	return out
+}
+
+// ConvertEscaped is like Convert for the raw text of a backslash-escaped
+// string literal, as found in PO files and double-quoted YAML: escape
+// sequences such as \n, \" and \u00e9 are copied unchanged too.
+func ConvertEscaped(raw string, convert func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 >= len(raw) {
+			continue
+		}
+		end := i + 2
+		switch c := raw[i+1]; {
+		case c == 'x':
+			end = i + 4
+		case c == 'u':
+			end = i + 6
+		case c == 'U':
+			end = i + 10
+		case c >= '0' && c <= '7':
+			for end < len(raw) && end < i+4 && raw[end] >= '0' && raw[end] <= '7' {
+				end++
+			}
+		}
+		if end > len(raw) {
+			end = len(raw)
+		}
+		b.WriteString(Convert(raw[last:i], convert))
+		b.WriteString(raw[i:end])
+		last = end
+		i = end - 1
+	}
+	b.WriteString(Convert(raw[last:], convert))
+	return b.String()
+}
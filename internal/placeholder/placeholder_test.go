@@ -0,0 +1,58 @@
+package placeholder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"hello {name}!", "HELLO {name}!"},
+		{"%s has %d items", "%s HAS %d ITEMS"},
+		{"%1$s and %(count)s, %.2f%%", "%1$s AND %(count)s, %.2f%%"},
+		{"{{.Count}} files", "{{.Count}} FILES"},
+		{"{{ .Name | upper }} ok", "{{ .Name | upper }} OK"},
+		{"index {0} of {1}", "INDEX {0} OF {1}"},
+		{"no placeholders", "NO PLACEHOLDERS"},
+		{"50% off", "50% OFF"},
+		{"100%ke shekem, 50%lı", "100%KE SHEKEM, 50%LI"},
+		{"20%ı %s-ke", "20%I %s-KE"},
+		{"100%ese %5.1fkm", "100%ESE %5.1fKM"},
+	}
+
+	for _, tt := range tests {
+		if got := Convert(tt.input, strings.ToUpper); got != tt.want {
+			t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	got := Find("{a} %s {{.B}} %%")
+	want := []string{"{a}", "%s", "{{.B}}", "%%"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find = %q, want %q", got, want)
+	}
+}
+
+func TestConvertEscaped(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`line\nnext`, `LINE\nNEXT`},
+		{`say \"hi\" %s`, `SAY \"HI\" %s`},
+		{`\u00e9t\x41b \303c`, `\u00e9T\x41B \303C`},
+		{`trailing \`, `TRAILING \`},
+	}
+
+	for _, tt := range tests {
+		if got := ConvertEscaped(tt.input, strings.ToUpper); got != tt.want {
+			t.Errorf("ConvertEscaped(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
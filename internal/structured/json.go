@@ -0,0 +1,125 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/placeholder"
+)
+
+// container is an open JSON object or array.
+type container struct {
+	object    bool
+	expectKey bool
+	loc       elem
+}
+
+// ConvertJSON applies convert to the selected string values of a JSON
+// document, keeping placeholders such as {name} and %s. Keys and layout
+// are copied unchanged; a string is re-encoded only when its text
+// changes.
+func ConvertJSON(src string, convert func(string) string, sel Selector) (string, error) {
+	var v any
+	if err := json.Unmarshal([]byte(src), &v); err != nil {
+		return "", jsonError(src, err)
+	}
+
+	var (
+		b     strings.Builder
+		stack []container
+	)
+	loc := func() []elem {
+		l := make([]elem, 0, len(stack))
+		for _, c := range stack {
+			l = append(l, c.loc)
+		}
+		return l
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch c {
+		case '{', '[':
+			stack = append(stack, container{object: c == '{', expectKey: c == '{', loc: elem{isIndex: c == '['}})
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+		case ',':
+			top := &stack[len(stack)-1]
+			if top.object {
+				top.expectKey = true
+			} else {
+				top.loc.index++
+			}
+		case ':':
+			stack[len(stack)-1].expectKey = false
+		case '"':
+			end := stringEnd(src, i)
+			lit := src[i:end]
+			var s string
+			if err := json.Unmarshal([]byte(lit), &s); err != nil {
+				return "", jsonError(src, err)
+			}
+
+			if n := len(stack); n > 0 && stack[n-1].object && stack[n-1].expectKey {
+				stack[n-1].loc.key = s
+			} else if sel.selects(loc()) {
+				if conv := placeholder.Convert(s, convert); conv != s {
+					lit = encodeJSONString(conv)
+				}
+			}
+			b.WriteString(lit)
+			i = end
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), nil
+}
+
+// stringEnd returns the offset just past the JSON string literal that
+// starts at src[start].
+func stringEnd(src string, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// encodeJSONString encodes s as a JSON string without escaping <, > and &.
+func encodeJSONString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonError adds the line and column of a syntax error.
+func jsonError(src string, err error) error {
+	var syn *json.SyntaxError
+	if errors.As(err, &syn) {
+		line, col := position(src, int(syn.Offset))
+		return fmt.Errorf("invalid JSON at line %d, column %d: %s", line, col, syn)
+	}
+	return fmt.Errorf("invalid JSON: %w", err)
+}
+
+// position returns the 1-based line and column of a byte offset.
+func position(src string, offset int) (line, col int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	before := src[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+	return line, col
+}
@@ -0,0 +1,182 @@
+// Package structured converts the string values of JSON and YAML
+// documents, leaving keys, numbers, booleans and layout unchanged.
+package structured
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath is returned when a path selector cannot be parsed.
+var ErrInvalidPath = errors.New("invalid path")
+
+// elem is one step of the location of a value: an object key or an
+// array index.
+type elem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+// step is one step of a selector. A descendant step may skip any number
+// of levels before matching.
+type step struct {
+	kind       stepKind
+	key        string
+	index      int
+	descendant bool
+}
+
+func (s step) matches(e elem) bool {
+	switch s.kind {
+	case stepKey:
+		return !e.isIndex && e.key == s.key
+	case stepIndex:
+		return e.isIndex && e.index == s.index
+	}
+	return true
+}
+
+// Selector is a set of JSONPath-style paths such as $.messages.*,
+// $..title, $.items[0] or $['key with spaces']. A value is selected when
+// a path matches it or one of its ancestors. The zero Selector selects
+// every value.
+type Selector struct {
+	paths [][]step
+}
+
+// ParseSelector parses paths into a Selector.
+func ParseSelector(paths []string) (Selector, error) {
+	var sel Selector
+	for _, p := range paths {
+		steps, err := parsePath(p)
+		if err != nil {
+			return Selector{}, err
+		}
+		sel.paths = append(sel.paths, steps)
+	}
+	return sel, nil
+}
+
+// selects reports whether the value at loc is selected.
+func (sel Selector) selects(loc []elem) bool {
+	if len(sel.paths) == 0 {
+		return true
+	}
+	for _, steps := range sel.paths {
+		if matchPrefix(steps, loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPrefix reports whether steps match loc or one of its prefixes.
+func matchPrefix(steps []step, loc []elem) bool {
+	if len(steps) == 0 {
+		return true
+	}
+	s := steps[0]
+	for i := range loc {
+		if i > 0 && !s.descendant {
+			break
+		}
+		if s.matches(loc[i]) && matchPrefix(steps[1:], loc[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePath(p string) ([]step, error) {
+	bad := func(why string) error {
+		return fmt.Errorf("%w %q: %s", ErrInvalidPath, p, why)
+	}
+
+	s := strings.TrimSpace(p)
+	s = strings.TrimPrefix(s, "$")
+	if s == "" {
+		return nil, nil
+	}
+
+	var steps []step
+	for s != "" {
+		var st step
+		switch {
+		case strings.HasPrefix(s, ".."):
+			st.descendant = true
+			s = s[2:]
+			if strings.HasPrefix(s, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(s, "."):
+			s = strings.TrimPrefix(s, ".")
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			if name == "" {
+				return nil, bad("empty key")
+			}
+			if name == "*" {
+				st.kind = stepWildcard
+			} else {
+				st.kind = stepKey
+				st.key = name
+			}
+			steps = append(steps, st)
+			continue
+		case strings.HasPrefix(s, "["):
+		default:
+			return nil, bad("expected '.' or '['")
+		}
+
+		// Bracket step: [0], [*], ['key'] or ["key"].
+		s = s[1:]
+		switch {
+		case strings.HasPrefix(s, "'") || strings.HasPrefix(s, `"`):
+			q := s[0]
+			end := strings.IndexByte(s[1:], q)
+			if end < 0 {
+				return nil, bad("unterminated quote")
+			}
+			st.kind = stepKey
+			st.key = s[1 : end+1]
+			s = s[end+2:]
+		case strings.HasPrefix(s, "*"):
+			st.kind = stepWildcard
+			s = s[1:]
+		default:
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, bad("missing ']'")
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(s[:end]))
+			if err != nil || n < 0 {
+				return nil, bad("array index must be a non-negative number")
+			}
+			st.kind = stepIndex
+			st.index = n
+			s = s[end:]
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, bad("missing ']'")
+		}
+		s = s[1:]
+		steps = append(steps, st)
+	}
+	return steps, nil
+}
@@ -0,0 +1,124 @@
+package structured
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func mustSelector(t *testing.T, paths ...string) Selector {
+	t.Helper()
+	sel, err := ParseSelector(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sel
+}
+
+func TestConvertJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		input string
+		want  string
+	}{
+		{"values only", nil, `{"greeting": "hello", "n": 1, "ok": true}`, `{"greeting": "HELLO", "n": 1, "ok": true}`},
+		{"nested", nil, "{\n  \"a\": {\"b\": [\"x\", \"y\"]}\n}\n", "{\n  \"a\": {\"b\": [\"X\", \"Y\"]}\n}\n"},
+		{"placeholders", nil, `{"m": "hi {name}, %s has {{.Count}}"}`, `{"m": "HI {name}, %s HAS {{.Count}}"}`},
+		{"escapes kept when unchanged", nil, `{"a": "\u0021", "b": "1\n2"}`, `{"a": "\u0021", "b": "1\n2"}`},
+		{"escapes re-encoded", nil, `{"a": "x\ty<&>"}`, `{"a": "X\tY<&>"}`},
+		{"wildcard", []string{"$.messages.*"}, `{"messages": {"a": "x"}, "meta": {"a": "x"}}`, `{"messages": {"a": "X"}, "meta": {"a": "x"}}`},
+		{"subtree", []string{"$.messages"}, `{"messages": {"a": {"b": "x"}}, "id": "x"}`, `{"messages": {"a": {"b": "X"}}, "id": "x"}`},
+		{"descendant", []string{"$..title"}, `{"a": {"title": "x", "id": "x"}, "title": "y"}`, `{"a": {"title": "X", "id": "x"}, "title": "Y"}`},
+		{"index", []string{"$.list[1]"}, `{"list": ["a", "b", "c"]}`, `{"list": ["a", "B", "c"]}`},
+		{"quoted key", []string{"$['a b']"}, `{"a b": "x", "c": "x"}`, `{"a b": "X", "c": "x"}`},
+		{"root string", nil, `"x"`, `"X"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertJSON(tt.input, upper, mustSelector(t, tt.paths...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertJSON(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertJSONInvalid(t *testing.T) {
+	_, err := ConvertJSON("{\n  \"a\": x\n}", upper, Selector{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want a line 2 error", err)
+	}
+}
+
+func TestConvertYAML(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		input string
+		want  string
+	}{
+		{"plain", nil, "greeting: hello # comment\ncount: 3\n", "greeting: HELLO # comment\ncount: 3\n"},
+		{"quoted", nil, "a: 'it''s'\nb: \"x\\ny \\u0021\"\n", "a: 'IT''S'\nb: \"X\\nY \\u0021\"\n"},
+		{"block", nil, "text: |\n  one\n  two\nnext: x\n", "text: |\n  ONE\n  TWO\nnext: X\n"},
+		{"list and flow", nil, "items:\n  - one\n  - [two, 'three']\n", "items:\n  - ONE\n  - [TWO, 'THREE']\n"},
+		{"placeholders", nil, "m: \"{name} has %d\"\n", "m: \"{name} HAS %d\"\n"},
+		{"non-ascii keys", nil, "сәлем: [а, b]\n", "сәлем: [А, B]\n"},
+		{"anchors", nil, "a: &x hello\nb: *x\n", "a: &x HELLO\nb: *x\n"},
+		{"anchor next to a comment", nil, "a: &x shahar\nb:   sh   # c\n", "a: &x SHAHAR\nb:   SH   # c\n"},
+		{"tags", nil, "a: !!str  hi\nb: &y !!str 'x'  # c\nc: &z\n  |\n    text\n", "a: !!str  HI\nb: &y !!str 'X'  # c\nc: &z\n  |\n    TEXT\n"},
+		{"selector", []string{"$.messages.*"}, "messages:\n  hi: hello\nid: hello\n", "messages:\n  hi: HELLO\nid: hello\n"},
+		{"documents", nil, "a: x\n---\nb: y\n", "a: X\n---\nb: Y\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertYAML(tt.input, upper, mustSelector(t, tt.paths...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertYAML(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertYAMLReencodes(t *testing.T) {
+	// "null" would change type if written in place, so the document is
+	// re-encoded with the value quoted.
+	got, err := ConvertYAML("a: nul\n", func(s string) string { return s + "l" }, Selector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a: \"null\"\n" {
+		t.Errorf("ConvertYAML = %q", got)
+	}
+}
+
+func TestConvertYAMLUnlocated(t *testing.T) {
+	// A plain scalar folded over two lines has no source text equal to
+	// its value, and the stream is not reformatted to rewrite it.
+	_, err := ConvertYAML("# c\na: one\n  two\n", upper, Selector{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want a line 2 error", err)
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, p := range []string{"$.", "$[x]", "$['a", "$.a[1", "a"} {
+		if _, err := ParseSelector([]string{p}); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("ParseSelector(%q) err = %v, want ErrInvalidPath", p, err)
+		}
+	}
+}
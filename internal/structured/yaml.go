@@ -0,0 +1,329 @@
+package structured
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dontbeidle/kaalin/internal/placeholder"
+)
+
+// edit replaces src[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// ConvertYAML applies convert to the selected string values of a YAML
+// stream, keeping placeholders such as {name} and %s. Values are rewritten
+// in place so that keys, comments, anchors and layout survive. A value
+// whose source text cannot be found is an error rather than a reason to
+// reformat the stream; only a rewrite that would change how a value
+// parses, such as nul becoming null, makes the documents be re-encoded
+// from their parsed form.
+func ConvertYAML(src string, convert func(string) string, sel Selector) (string, error) {
+	docs, err := parseYAML(src)
+	if err != nil {
+		return "", err
+	}
+
+	value := func(s string) string {
+		return placeholder.Convert(s, convert)
+	}
+	lines := lineStarts(src)
+
+	var (
+		edits   []edit
+		missing *yaml.Node
+	)
+	for _, doc := range docs {
+		walkYAML(doc, nil, sel, func(n *yaml.Node) {
+			if missing != nil || value(n.Value) == n.Value {
+				return
+			}
+			e, ok := scalarEdit(src, lines, n, convert)
+			if !ok {
+				missing = n
+				return
+			}
+			edits = append(edits, e)
+		})
+	}
+	if missing != nil {
+		return "", fmt.Errorf("line %d, column %d: cannot locate the value to rewrite it in place", missing.Line, missing.Column)
+	}
+
+	out := applyEdits(src, edits)
+	if got, err := parseYAML(out); err == nil && sameYAML(docs, got, sel, value) {
+		return out, nil
+	}
+	return reencodeYAML(docs, sel, value)
+}
+
+// parseYAML parses every document of a YAML stream.
+func parseYAML(src string) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+// walkYAML calls fn for every selected string scalar under n. Keys,
+// aliases and non-string scalars are skipped.
+func walkYAML(n *yaml.Node, loc []elem, sel Selector, fn func(*yaml.Node)) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			walkYAML(c, loc, sel, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if k.Value == "<<" && k.ShortTag() == "!!merge" {
+				continue
+			}
+			walkYAML(n.Content[i+1], append(loc[:len(loc):len(loc)], elem{key: k.Value}), sel, fn)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkYAML(c, append(loc[:len(loc):len(loc)], elem{index: i, isIndex: true}), sel, fn)
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" && sel.selects(loc) {
+			fn(n)
+		}
+	}
+}
+
+// scalarEdit locates the source text of a scalar and converts it in
+// place. It reports false for scalars it cannot locate safely.
+func scalarEdit(src string, lines []int, n *yaml.Node, convert func(string) string) (edit, bool) {
+	value := func(s string) string {
+		return placeholder.Convert(s, convert)
+	}
+	start, ok := offset(src, lines, n.Line, n.Column)
+	if !ok {
+		return edit{}, false
+	}
+	// The position of a node with an anchor or tag is that of the first
+	// of them, not of the scalar itself.
+	start = skipProperties(src, start)
+	rest := src[start:]
+
+	switch n.Style &^ yaml.TaggedStyle {
+	case 0:
+		if !strings.HasPrefix(rest, n.Value) || strings.ContainsAny(n.Value, "\n") {
+			return edit{}, false
+		}
+		return edit{start, start + len(n.Value), value(n.Value)}, true
+
+	case yaml.SingleQuotedStyle, yaml.DoubleQuotedStyle:
+		q := rest[0]
+		if q != '\'' && q != '"' {
+			return edit{}, false
+		}
+		end := quoteEnd(rest, q)
+		if end < 0 {
+			return edit{}, false
+		}
+		inner := rest[1:end]
+		if q == '"' {
+			return edit{start + 1, start + end, placeholder.ConvertEscaped(inner, convert)}, true
+		}
+		return edit{start + 1, start + end, value(inner)}, true
+
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		if rest == "" || (rest[0] != '|' && rest[0] != '>') {
+			return edit{}, false
+		}
+		header := strings.IndexByte(rest, '\n')
+		if header < 0 {
+			return edit{}, false
+		}
+		body := rest[header+1:]
+		end := blockEnd(body)
+		return edit{start + header + 1, start + header + 1 + end, value(body[:end])}, true
+	}
+	return edit{}, false
+}
+
+// skipProperties returns the offset of the first byte at or after pos
+// that is not part of an &anchor, *alias or !tag token, or of the spaces,
+// line breaks and comments after one.
+func skipProperties(src string, pos int) int {
+	for pos < len(src) && strings.IndexByte("&*!", src[pos]) >= 0 {
+		for pos < len(src) && !strings.ContainsRune(" \t\r\n", rune(src[pos])) {
+			pos++
+		}
+		for pos < len(src) {
+			switch src[pos] {
+			case ' ', '\t', '\r', '\n':
+				pos++
+				continue
+			case '#':
+				if end := strings.IndexByte(src[pos:], '\n'); end >= 0 {
+					pos += end
+					continue
+				}
+				pos = len(src)
+			}
+			break
+		}
+	}
+	return pos
+}
+
+// quoteEnd returns the offset of the closing quote of the scalar that
+// starts at s[0], or -1.
+func quoteEnd(s string, q byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// blockEnd returns the length of the body of a block scalar: the lines
+// indented at least as much as its first non-blank line.
+func blockEnd(body string) int {
+	indent := -1
+	pos := 0
+	end := 0
+	for pos < len(body) {
+		next := strings.IndexByte(body[pos:], '\n')
+		line := body[pos:]
+		if next >= 0 {
+			line = body[pos : pos+next+1]
+		}
+		content := strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(content) != "" {
+			n := len(content) - len(strings.TrimLeft(content, " "))
+			if indent < 0 {
+				indent = n
+			}
+			if n < indent || n == 0 {
+				break
+			}
+			end = pos + len(line)
+		}
+		pos += len(line)
+	}
+	return end
+}
+
+// lineStarts returns the byte offset of the start of every line.
+func lineStarts(src string) []int {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// offset converts a 1-based line and rune column into a byte offset.
+func offset(src string, lines []int, line, col int) (int, bool) {
+	if line < 1 || line > len(lines) || col < 1 {
+		return 0, false
+	}
+	pos := lines[line-1]
+	for c := 1; c < col; c++ {
+		if pos >= len(src) || src[pos] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(src[pos:])
+		pos += size
+	}
+	return pos, true
+}
+
+func applyEdits(src string, edits []edit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		b.WriteString(src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// sameYAML reports whether got has the structure of want with exactly the
+// selected string values converted.
+func sameYAML(want, got []*yaml.Node, sel Selector, value func(string) string) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	var flat func(n *yaml.Node, out *[]string)
+	flat = func(n *yaml.Node, out *[]string) {
+		*out = append(*out, fmt.Sprint(n.Kind, n.ShortTag(), n.Value, len(n.Content)))
+		for _, c := range n.Content {
+			flat(c, out)
+		}
+	}
+	for i := range want {
+		var expected, actual []string
+		converted := cloneNode(want[i])
+		walkYAML(converted, nil, sel, func(n *yaml.Node) { n.Value = value(n.Value) })
+		flat(converted, &expected)
+		flat(got[i], &actual)
+		if strings.Join(expected, "\x00") != strings.Join(actual, "\x00") {
+			return false
+		}
+	}
+	return true
+}
+
+// reencodeYAML converts the selected values of the parsed documents and
+// encodes them again.
+func reencodeYAML(docs []*yaml.Node, sel Selector, value func(string) string) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		walkYAML(doc, nil, sel, func(n *yaml.Node) { n.Value = value(n.Value) })
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
@@ -0,0 +1,181 @@
+// Package subtitle converts the cue text of SubRip (SRT) and WebVTT
+// subtitles, keeping cue numbers, identifiers, timings, cue settings,
+// NOTE, STYLE and REGION blocks and inline tags unchanged.
+package subtitle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/markup"
+)
+
+// Cue is a subtitle cue.
+type Cue struct {
+	// Line is the 1-based line number of the timing line.
+	Line   int
+	ID     string
+	Timing string
+	Text   string
+}
+
+// ErrStructureChanged is returned when converted subtitles no longer
+// parse into the same cues.
+var ErrStructureChanged = errors.New("conversion changed the subtitle structure")
+
+var (
+	srtTiming = regexp.MustCompile(`^\s*\d{1,3}:\d{2}:\d{2}[,.]\d{1,3}\s*-->\s*\d{1,3}:\d{2}:\d{2}[,.]\d{1,3}`)
+	vttTiming = regexp.MustCompile(`^(\d{2,}:)?\d{2}:\d{2}\.\d{3}[ \t]+-->[ \t]+(\d{2,}:)?\d{2}:\d{2}\.\d{3}([ \t]|$)`)
+
+	// assTag matches {\an8}-style override tags found in SRT files.
+	assTag = regexp.MustCompile(`\{\\[^{}]*\}`)
+)
+
+// ParseSRT parses SubRip subtitles.
+func ParseSRT(src string) ([]Cue, error) {
+	_, cues, err := walk(src, false, nil)
+	return cues, err
+}
+
+// ParseVTT parses WebVTT subtitles.
+func ParseVTT(src string) ([]Cue, error) {
+	_, cues, err := walk(src, true, nil)
+	return cues, err
+}
+
+// ConvertSRT applies convert to the cue text of SubRip subtitles.
+func ConvertSRT(src string, convert func(string) string) (string, error) {
+	return convertCues(src, false, convert)
+}
+
+// ConvertVTT applies convert to the cue text of WebVTT subtitles.
+func ConvertVTT(src string, convert func(string) string) (string, error) {
+	return convertCues(src, true, convert)
+}
+
+// convertCues converts the cue text of src and checks that the result
+// parses into the same cues with the same timings.
+func convertCues(src string, vtt bool, convert func(string) string) (string, error) {
+	out, cues, err := walk(src, vtt, func(text string) string {
+		return cueText(text, convert)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, got, err := walk(out, vtt, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrStructureChanged, err)
+	}
+	if len(got) != len(cues) {
+		return "", ErrStructureChanged
+	}
+	for i := range got {
+		if got[i].ID != cues[i].ID || got[i].Timing != cues[i].Timing {
+			return "", fmt.Errorf("%w: line %d", ErrStructureChanged, got[i].Line)
+		}
+	}
+	return out, nil
+}
+
+// cueText converts cue text around inline tags and {\...} overrides.
+func cueText(text string, convert func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range assTag.FindAllStringIndex(text, -1) {
+		b.WriteString(markup.Convert(text[last:m[0]], convert, markup.Options{}))
+		b.WriteString(text[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(markup.Convert(text[last:], convert, markup.Options{}))
+	return b.String()
+}
+
+// walk parses src cue by cue, copying it to the returned string with the
+// text of each cue passed through convert if it is not nil.
+func walk(src string, vtt bool, convert func(string) string) (string, []Cue, error) {
+	var (
+		b    strings.Builder
+		cues []Cue
+	)
+	lines := strings.SplitAfter(src, "\n")
+	i := 0
+
+	if vtt {
+		header := strings.TrimPrefix(trimEOL(lines[0]), "\uFEFF")
+		if !strings.HasPrefix(header, "WEBVTT") || (len(header) > 6 && header[6] != ' ' && header[6] != '\t') {
+			return "", nil, errors.New("line 1: missing WEBVTT header")
+		}
+		for ; i < len(lines) && !isBlank(lines[i]); i++ {
+			b.WriteString(lines[i])
+		}
+	}
+
+	for i < len(lines) {
+		if isBlank(lines[i]) {
+			b.WriteString(lines[i])
+			i++
+			continue
+		}
+
+		if vtt && isVTTBlock(trimEOL(lines[i])) {
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				b.WriteString(lines[i])
+			}
+			continue
+		}
+
+		timing := srtTiming
+		if vtt {
+			timing = vttTiming
+		}
+
+		var cue Cue
+		if !timing.MatchString(trimEOL(lines[i])) {
+			cue.ID = trimEOL(lines[i])
+			b.WriteString(lines[i])
+			i++
+			if i >= len(lines) || !timing.MatchString(trimEOL(lines[i])) {
+				return "", nil, fmt.Errorf("line %d: expected a cue timing line", i+1)
+			}
+		}
+		cue.Line = i + 1
+		cue.Timing = trimEOL(lines[i])
+		b.WriteString(lines[i])
+		i++
+
+		var text strings.Builder
+		for ; i < len(lines) && !isBlank(lines[i]); i++ {
+			text.WriteString(lines[i])
+		}
+		cue.Text = text.String()
+		if convert != nil {
+			b.WriteString(convert(cue.Text))
+		} else {
+			b.WriteString(cue.Text)
+		}
+		cues = append(cues, cue)
+	}
+
+	return b.String(), cues, nil
+}
+
+// isVTTBlock reports whether line starts a NOTE, STYLE or REGION block.
+func isVTTBlock(line string) bool {
+	for _, kw := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == kw || strings.HasPrefix(line, kw+" ") || strings.HasPrefix(line, kw+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+func trimEOL(line string) string {
+	return strings.TrimRight(line, "\r\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
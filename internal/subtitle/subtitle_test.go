@@ -0,0 +1,76 @@
+package subtitle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func TestConvertSRT(t *testing.T) {
+	input := "1\r\n00:00:01,000 --> 00:00:04,000\r\n<i>sálem</i> dúnya\r\n{\\an8}joqarı\r\n\r\n" +
+		"2\r\n00:00:05,000 --> 00:00:06,500 X1:10 X2:20\r\nshahar &amp; awıl\r\n"
+	want := "1\r\n00:00:01,000 --> 00:00:04,000\r\n<i>SÁLEM</i> DÚNYA\r\n{\\an8}JOQARI\r\n\r\n" +
+		"2\r\n00:00:05,000 --> 00:00:06,500 X1:10 X2:20\r\nSHAHAR &amp; AWIL\r\n"
+
+	got, err := ConvertSRT(input, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ConvertSRT =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestConvertVTT(t *testing.T) {
+	input := "WEBVTT - film\nKind: captions\n\n" +
+		"NOTE this is a note\nabout shahar\n\n" +
+		"STYLE\n::cue(.yellow) { color: yellow; }\n\n" +
+		"intro\n00:01.000 --> 00:04.000 align:start position:10%\n<v Bob><c.yellow>sálem</c></v> <00:02.500>dúnya\n\n" +
+		"00:05.000 --> 00:06.000\nqala\n"
+	want := "WEBVTT - film\nKind: captions\n\n" +
+		"NOTE this is a note\nabout shahar\n\n" +
+		"STYLE\n::cue(.yellow) { color: yellow; }\n\n" +
+		"intro\n00:01.000 --> 00:04.000 align:start position:10%\n<v Bob><c.yellow>SÁLEM</c></v> <00:02.500>DÚNYA\n\n" +
+		"00:05.000 --> 00:06.000\nQALA\n"
+
+	got, err := ConvertVTT(input, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ConvertVTT =\n%q\nwant\n%q", got, want)
+	}
+
+	cues, err := ParseVTT(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 2 || cues[0].ID != "intro" || cues[1].Line != 14 {
+		t.Errorf("ParseVTT = %+v", cues)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := ParseVTT("1\n00:01.000 --> 00:02.000\nx\n"); err == nil {
+		t.Error("ParseVTT without header: no error")
+	}
+	if _, err := ParseSRT("1\nnot a timing\ntext\n"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ParseSRT with a bad timing: err = %v, want line 2", err)
+	}
+}
+
+func TestConvertValidates(t *testing.T) {
+	// A cue line that converts to nothing splits the cue in two.
+	_, err := ConvertSRT("1\n00:00:01,000 --> 00:00:02,000\na\nx\n\n2\n00:00:03,000 --> 00:00:04,000\ny\n", func(s string) string {
+		return strings.ReplaceAll(s, "a\n", "\n")
+	})
+	if !errors.Is(err, ErrStructureChanged) {
+		t.Errorf("err = %v, want ErrStructureChanged", err)
+	}
+}
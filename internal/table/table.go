@@ -0,0 +1,368 @@
+// Package table converts selected columns of CSV and TSV files row by
+// row. Only the cells whose text changes are rewritten: every other
+// field, the quoting and the line endings are copied from the input as
+// they are. TSV has no quoting, so its rows are split on tabs as they
+// are.
+package table
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownColumn is returned when a selected column is neither a header
+// name nor a valid column number.
+var ErrUnknownColumn = errors.New("unknown column")
+
+const utf8BOM = "\uFEFF"
+
+// Options controls which columns are converted.
+type Options struct {
+	// Comma is the field separator: ',' for CSV, '\t' for TSV. Zero means
+	// ','.
+	Comma rune
+
+	// Columns selects the columns to convert by header name or 1-based
+	// number. With no columns every cell is converted.
+	Columns []string
+
+	// Suffix, if set, keeps the selected columns and appends a converted
+	// copy of each, named after its header with Suffix added.
+	Suffix string
+}
+
+// Convert reads a table from src, whose first row is the header, and
+// writes it to dst with the selected columns passed through convert. The
+// header row is never converted. Rows are streamed, so memory use does
+// not grow with the input. A leading byte order mark is copied to dst
+// and is not part of the first header name.
+func Convert(dst io.Writer, src io.Reader, convert func(string) string, opts Options) error {
+	comma := opts.Comma
+	if comma == 0 {
+		comma = ','
+	}
+
+	br := bufio.NewReader(src)
+	bw := bufio.NewWriter(dst)
+	if bom, _ := br.Peek(len(utf8BOM)); string(bom) == utf8BOM {
+		br.Discard(len(utf8BOM))
+		bw.WriteString(utf8BOM)
+	}
+
+	var r rowReader
+	if comma == '\t' {
+		r = &tsvReader{br: br}
+	} else {
+		r = &csvReader{br: br, comma: string(comma)}
+	}
+
+	var (
+		cols   []int
+		width  int
+		header = true
+	)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(rec.fields) == 0 {
+			bw.WriteString(rec.end)
+			continue
+		}
+
+		row := append([]string(nil), rec.raw...)
+		// set replaces cell i with value, quoted as needed.
+		set := func(i int, value string) error {
+			quoted := i < len(rec.raw) && strings.HasPrefix(rec.raw[i], `"`)
+			field, ok := r.Field(value, quoted)
+			if !ok {
+				return fmt.Errorf("line %d: cell %d contains a tab or line break, which TSV cannot hold", rec.line, i+1)
+			}
+			if i < len(row) {
+				row[i] = field
+			} else {
+				row = append(row, field)
+			}
+			return nil
+		}
+
+		var cells []string
+		switch {
+		case header:
+			if cols, err = resolveColumns(rec.fields, opts.Columns); err != nil {
+				return err
+			}
+			width = len(rec.fields)
+			header = false
+			if opts.Suffix != "" {
+				cells = appended(rec.fields, width, cols, func(s string) string { return s + opts.Suffix })
+			}
+		case opts.Suffix != "":
+			cells = appended(rec.fields, width, cols, convert)
+		case cols == nil:
+			for i, cell := range rec.fields {
+				if v := convert(cell); v != cell {
+					if err := set(i, v); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			for _, c := range cols {
+				if c < len(rec.fields) {
+					if v := convert(rec.fields[c]); v != rec.fields[c] {
+						if err := set(c, v); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		for _, cell := range cells {
+			if err := set(len(row), cell); err != nil {
+				return err
+			}
+		}
+
+		bw.WriteString(strings.Join(row, r.Comma()))
+		if _, err := bw.WriteString(rec.end); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// appended returns the cells added to row for the selected columns, or
+// for all of them if cols is nil: first empty cells padding a short row
+// to the header width, so that the added cells line up with their
+// headers, then the converted copies.
+func appended(row []string, width int, cols []int, convert func(string) string) []string {
+	if cols == nil {
+		cols = make([]int, width)
+		for i := range cols {
+			cols[i] = i
+		}
+	}
+	out := make([]string, 0, max(width-len(row), 0)+len(cols))
+	for i := len(row); i < width; i++ {
+		out = append(out, "")
+	}
+	for _, c := range cols {
+		if c < len(row) {
+			out = append(out, convert(row[c]))
+		} else {
+			out = append(out, convert(""))
+		}
+	}
+	return out
+}
+
+// resolveColumns maps column names and 1-based numbers to indexes. A
+// header name takes precedence over a number. nil means every column.
+func resolveColumns(header []string, columns []string) ([]int, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	byName := make(map[string]int, len(header))
+	for i := len(header) - 1; i >= 0; i-- {
+		byName[strings.TrimSpace(header[i])] = i
+	}
+
+	cols := make([]int, 0, len(columns))
+	seen := make(map[int]bool)
+	for _, name := range columns {
+		name = strings.TrimSpace(name)
+		i, ok := byName[name]
+		if !ok {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w %q: not in the header", ErrUnknownColumn, name)
+			}
+			i = n - 1
+		}
+		if !seen[i] {
+			seen[i] = true
+			cols = append(cols, i)
+		}
+	}
+	return cols, nil
+}
+
+// record is a row as read from the input.
+type record struct {
+	// fields are the cell values; a blank CSV line has none.
+	fields []string
+	// raw are the fields as written in the input, quotes included.
+	raw []string
+	// end is the line ending: "\n", "\r\n", or "" at the end of input.
+	end string
+	// line is the line the record starts on.
+	line int
+}
+
+type rowReader interface {
+	Read() (*record, error)
+	// Comma returns the field separator.
+	Comma() string
+	// Field returns value as a field to write, quoting it if quoted is
+	// set or the value needs it. ok is false if it cannot be written.
+	Field(value string, quoted bool) (field string, ok bool)
+}
+
+// splitEnd splits a line read up to and including '\n' into its text and
+// its line ending.
+func splitEnd(line string) (text, end string) {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return line[:len(line)-2], "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
+}
+
+// csvReader reads RFC 4180 records, keeping the text of each field as
+// well as its value. Errors are *csv.ParseError values, as from
+// encoding/csv.
+type csvReader struct {
+	br    *bufio.Reader
+	comma string
+	line  int
+}
+
+func (r *csvReader) readLine() (string, error) {
+	line, err := r.br.ReadString('\n')
+	if line != "" {
+		r.line++
+	}
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return line, err
+}
+
+func (r *csvReader) Read() (*record, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	rec := &record{line: r.line}
+	text, end := splitEnd(line)
+	if text == "" {
+		rec.end = end
+		return rec, nil
+	}
+
+	for i := 0; ; {
+		if !strings.HasPrefix(text[i:], `"`) {
+			n := strings.Index(text[i:], r.comma)
+			if n < 0 {
+				n = len(text) - i
+			}
+			field := text[i : i+n]
+			if q := strings.IndexByte(field, '"'); q >= 0 {
+				return nil, &csv.ParseError{StartLine: rec.line, Line: r.line, Column: i + q + 1, Err: csv.ErrBareQuote}
+			}
+			rec.fields = append(rec.fields, field)
+			rec.raw = append(rec.raw, field)
+			i += n
+		} else {
+			start := i
+			var value strings.Builder
+			for i++; ; {
+				q := strings.IndexByte(text[i:], '"')
+				if q < 0 {
+					// The field goes on past the end of this line.
+					value.WriteString(text[i:])
+					value.WriteString(end)
+					more, err := r.readLine()
+					if err == io.EOF {
+						return nil, &csv.ParseError{StartLine: rec.line, Line: r.line, Column: len(text) + 1, Err: csv.ErrQuote}
+					}
+					if err != nil {
+						return nil, err
+					}
+					i = len(text) + len(end)
+					next, nextEnd := splitEnd(more)
+					text, end = text+end+next, nextEnd
+					continue
+				}
+				value.WriteString(text[i : i+q])
+				i += q + 1
+				if strings.HasPrefix(text[i:], `"`) {
+					value.WriteByte('"')
+					i++
+					continue
+				}
+				break
+			}
+			if i < len(text) && !strings.HasPrefix(text[i:], r.comma) {
+				return nil, &csv.ParseError{StartLine: rec.line, Line: r.line, Column: i + 1, Err: csv.ErrQuote}
+			}
+			rec.fields = append(rec.fields, value.String())
+			rec.raw = append(rec.raw, text[start:i])
+		}
+
+		if i == len(text) {
+			break
+		}
+		i += len(r.comma)
+		if i == len(text) {
+			// A trailing comma leaves an empty last field.
+			rec.fields = append(rec.fields, "")
+			rec.raw = append(rec.raw, "")
+			break
+		}
+	}
+	rec.end = end
+	return rec, nil
+}
+
+func (r *csvReader) Comma() string {
+	return r.comma
+}
+
+func (r *csvReader) Field(value string, quoted bool) (string, bool) {
+	if !quoted && !strings.ContainsAny(value, r.comma+"\"\r\n") && !strings.HasPrefix(value, " ") && !strings.HasPrefix(value, "\t") {
+		return value, true
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`, true
+}
+
+// tsvReader splits lines on tabs without any unquoting.
+type tsvReader struct {
+	br   *bufio.Reader
+	line int
+}
+
+func (r *tsvReader) Read() (*record, error) {
+	line, err := r.br.ReadString('\n')
+	if line == "" && err != nil {
+		return nil, err
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	r.line++
+	text, end := splitEnd(line)
+	fields := strings.Split(text, "\t")
+	return &record{fields: fields, raw: fields, end: end, line: r.line}, nil
+}
+
+func (r *tsvReader) Comma() string {
+	return "\t"
+}
+
+func (r *tsvReader) Field(value string, quoted bool) (string, bool) {
+	return value, !strings.ContainsAny(value, "\t\r\n")
+}
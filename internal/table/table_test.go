@@ -0,0 +1,63 @@
+package table
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// upper stands in for script conversion so that converted text is easy
+// to spot.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		input string
+		want  string
+	}{
+		{"all columns", Options{}, "name,city\nali,nókis\n", "name,city\nALI,NÓKIS\n"},
+		{"by name", Options{Columns: []string{"city"}}, "name,city\nali,nókis\n", "name,city\nali,NÓKIS\n"},
+		{"by number", Options{Columns: []string{"1"}}, "name,city\nali,nókis\n", "name,city\nALI,nókis\n"},
+		{"quoting", Options{Columns: []string{"address"}}, "id,address\n1,\"kóshe 5, \"\"a\"\"\nqala\"\n", "id,address\n1,\"KÓSHE 5, \"\"A\"\"\nQALA\"\n"},
+		{"append suffix", Options{Columns: []string{"name"}, Suffix: "_cyr"}, "id,name\n1,ali\n", "id,name,name_cyr\n1,ali,ALI\n"},
+		{"ragged rows", Options{Columns: []string{"c"}}, "a,b,c\n1\n1,2,x\n", "a,b,c\n1\n1,2,X\n"},
+		{"tsv", Options{Comma: '\t', Columns: []string{"b"}}, "a\tb\nx\t\"y, z\n", "a\tb\nx\t\"Y, Z\n"},
+		{"crlf", Options{}, "a\r\nx\r\n", "a\r\nX\r\n"},
+		{"empty", Options{Columns: []string{"a"}}, "", ""},
+		{"line breaks in cells", Options{Columns: []string{"b"}}, "a,b\r\n\"1\n2\",\"x\ny\"\r\n", "a,b\r\n\"1\n2\",\"X\nY\"\r\n"},
+		{"quoting kept", Options{Columns: []string{"b"}}, "a,b\n\"1\",\"x\"\n", "a,b\n\"1\",\"X\"\n"},
+		{"mixed line endings", Options{}, "a\nx\r\ny", "a\nX\r\nY"},
+		{"bom", Options{Columns: []string{"name"}}, "\uFEFFname,city\nali,nókis\n", "\uFEFFname,city\nALI,nókis\n"},
+		{"append to short row", Options{Columns: []string{"a"}, Suffix: "_cyr"}, "a,b,c\nx\n", "a,b,c,a_cyr\nx,,,X\n"},
+		{"append all columns", Options{Suffix: "_cyr"}, "a,b\nx\n", "a,b,a_cyr,b_cyr\nx,,X,\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Convert(&b, strings.NewReader(tt.input), upper, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Convert(%q) =\n%q\nwant\n%q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	var b strings.Builder
+	err := Convert(&b, strings.NewReader("a,b\n1,2\n"), upper, Options{Columns: []string{"address"}})
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("err = %v, want ErrUnknownColumn", err)
+	}
+
+	err = Convert(&b, strings.NewReader("a,b\n1,\"2\n"), upper, Options{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want a line 2 parse error", err)
+	}
+}
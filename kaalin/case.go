@@ -0,0 +1,13 @@
+package kaalin
+
+import "github.com/dontbeidle/kaalin/internal/strutil"
+
+// Upper converts text to uppercase, mapping dotless ı to Í.
+func Upper(text string) string {
+	return strutil.Upper(text)
+}
+
+// Lower converts text to lowercase, mapping Í to dotless ı.
+func Lower(text string) string {
+	return strutil.Lower(text)
+}
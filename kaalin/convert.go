@@ -0,0 +1,356 @@
+package kaalin
+
+import (
+	"io"
+	"os"
+
+	"github.com/dontbeidle/kaalin/internal/converter"
+	"github.com/dontbeidle/kaalin/internal/table"
+	"golang.org/x/text/transform"
+)
+
+// ConvertOptions controls script conversion.
+type ConvertOptions struct {
+	// To is the target script. Unknown converts to the opposite of the
+	// script detected in the text.
+	To Script
+
+	// Dictionary, if set, overrides the letter tables for whole words.
+	Dictionary *Dictionary
+
+	// From is the alphabet of the input. AlphabetLatinOld reads the
+	// apostrophe forms (a' o' u' g' n' i'); other values need no special
+	// handling.
+	From Alphabet
+
+	// ToAlphabet, if set, is the output alphabet and overrides To. With
+	// AlphabetLatinOld the output uses apostrophe forms.
+	ToAlphabet Alphabet
+
+	// Format is the document format. Formats other than FormatText
+	// convert only the human-readable text. Except for the row-based
+	// FormatCSV and FormatTSV they must be read whole, so ConvertStream
+	// buffers the input.
+	Format Format
+
+	// FrontMatter lists the Markdown front-matter fields, such as title
+	// and description, whose values are converted with FormatMarkdown.
+	FrontMatter []string
+
+	// Paths limits FormatJSON and FormatYAML to the values matched by
+	// JSONPath-style selectors such as $.messages.* or $..title, and
+	// everything below them. With no paths every string value is
+	// converted.
+	Paths []string
+
+	// Columns selects the FormatCSV and FormatTSV columns to convert by
+	// header name or 1-based number. With no columns every cell below the
+	// header is converted.
+	Columns []string
+
+	// AppendSuffix, if set, keeps the selected CSV or TSV columns as they
+	// are and appends converted copies named after their headers with
+	// the suffix added, e.g. "name_cyr".
+	AppendSuffix string
+
+	// MarkFuzzy marks the entries converted with FormatPO as fuzzy, and
+	// the targets converted with FormatXLIFF as needing review.
+	MarkFuzzy bool
+
+	// PerSegment converts only the segments found by DetectSegments that
+	// are not already in the target script, so that passages in the
+	// target script are copied unchanged.
+	PerSegment bool
+
+	// Lookalikes replaces look-alike letters such as ğ, ñ and һ with the
+	// Karakalpak letters ǵ, ń and ҳ before conversion. Text is composed
+	// to NFC either way; without Lookalikes the letters of other
+	// languages, such as Turkish or Bashkir words, are copied as they are.
+	Lookalikes bool
+}
+
+// Convert transliterates text according to opts.
+func Convert(text string, opts ConvertOptions) (string, error) {
+	if opts.Format != FormatText {
+		return convertDocument(text, opts)
+	}
+	to, err := resolveTarget(text, targetOf(opts))
+	if err != nil {
+		return "", err
+	}
+	return convertFunc(to, opts)(text), nil
+}
+
+// targetOf returns the requested target script of opts.
+func targetOf(opts ConvertOptions) Script {
+	if opts.ToAlphabet != AlphabetUnknown {
+		return opts.ToAlphabet.Script()
+	}
+	return opts.To
+}
+
+// convertFunc returns the whole-string conversion for a resolved target.
+func convertFunc(to Script, opts ConvertOptions) func(string) string {
+	convert := converter.Cyrillic2Latin
+	if to == Cyrillic {
+		convert = converter.Latin2Cyrillic
+	}
+	if opts.Dictionary != nil {
+		convert = opts.Dictionary.d.Cyrillic2Latin
+		if to == Cyrillic {
+			convert = opts.Dictionary.d.Latin2Cyrillic
+		}
+	}
+	convert = normalized(convert, opts.Lookalikes)
+
+	if needsLineContext(to, opts) {
+		letters := convert
+		convert = func(text string) string {
+			if opts.From == AlphabetLatinOld {
+				text = converter.OldLatinToLatin(text)
+			}
+			text = letters(text)
+			if to == Latin && opts.ToAlphabet == AlphabetLatinOld {
+				text = converter.LatinToOldLatin(text)
+			}
+			return text
+		}
+	}
+
+	// Changing the Latin alphabet touches Latin passages too, so only a
+	// Cyrillic target has segments to leave alone.
+	if opts.PerSegment && !(to == Latin && needsLineContext(to, opts)) {
+		return convertSegments(to, convert)
+	}
+	return convert
+}
+
+// needsLineContext reports whether the conversion reads or writes the
+// old apostrophe alphabet, whose quote handling spans a line.
+func needsLineContext(to Script, opts ConvertOptions) bool {
+	return opts.From == AlphabetLatinOld || (to == Latin && opts.ToAlphabet == AlphabetLatinOld)
+}
+
+// normalized returns convert applied to text composed to NFC, with
+// look-alike letters replaced if lookalikes is set.
+func normalized(convert func(string) string, lookalikes bool) func(string) string {
+	return func(text string) string {
+		text, _ = converter.Normalize(text, lookalikes)
+		return convert(text)
+	}
+}
+
+// Latin2Cyrillic converts Karakalpak Latin text to Cyrillic.
+func Latin2Cyrillic(text string) string {
+	return normalized(converter.Latin2Cyrillic, false)(text)
+}
+
+// Cyrillic2Latin converts Karakalpak Cyrillic text to Latin.
+func Cyrillic2Latin(text string) string {
+	return normalized(converter.Cyrillic2Latin, false)(text)
+}
+
+// ConvertStream converts src into dst chunk by chunk in constant memory.
+// The output is byte-for-byte identical to Convert on the whole input.
+// If opts.To is Unknown the direction is detected over all of src, which
+// is first copied to a temporary file unless it can seek. CSV and TSV
+// are converted row by row; other document formats are read whole
+// before they are converted.
+func ConvertStream(dst io.Writer, src io.Reader, opts ConvertOptions) error {
+	to := targetOf(opts)
+	rows := opts.Format == FormatCSV || opts.Format == FormatTSV
+	if to == Unknown && (opts.Format == FormatText || rows) {
+		rs, cleanup, err := rewindable(src)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if to, err = detectTarget(rs, opts); err != nil {
+			return err
+		}
+		src = rs
+	}
+
+	opts.To = to
+	if rows {
+		if to != Latin && to != Cyrillic {
+			return ErrUnknownScript
+		}
+		cellOpts := opts
+		cellOpts.Format = FormatText
+		return table.Convert(dst, src, convertFunc(to, cellOpts), tableOptions(opts))
+	}
+
+	t, err := NewTransformer(opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, transform.NewReader(src, t))
+	return err
+}
+
+// rewindable returns src as an io.ReadSeeker positioned where src was,
+// copying it to a temporary file if it cannot seek. cleanup removes the
+// file.
+func rewindable(src io.Reader) (io.ReadSeeker, func(), error) {
+	if rs, ok := src.(io.ReadSeeker); ok {
+		if pos, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return &offsetReader{rs, pos}, func() {}, nil
+		}
+	}
+
+	f, err := os.CreateTemp("", "kaalin-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return f, cleanup, nil
+}
+
+// offsetReader is an io.ReadSeeker whose start is offset bytes into rs.
+type offsetReader struct {
+	rs     io.ReadSeeker
+	offset int64
+}
+
+func (r *offsetReader) Read(p []byte) (int, error) {
+	return r.rs.Read(p)
+}
+
+func (r *offsetReader) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekStart {
+		offset += r.offset
+	}
+	pos, err := r.rs.Seek(offset, whence)
+	return pos - r.offset, err
+}
+
+// detectTarget reads all of rs to find the direction Convert would pick
+// for it, and rewinds rs. For CSV and TSV only the converted cells count,
+// as in Convert.
+func detectTarget(rs io.ReadSeeker, opts ConvertOptions) (Script, error) {
+	var script string
+	if opts.Format == FormatCSV || opts.Format == FormatTSV {
+		var d converter.ScriptDetector
+		err := table.Convert(io.Discard, rs, func(s string) string {
+			d.Add(s)
+			return s
+		}, tableOptions(opts))
+		if err != nil {
+			return Unknown, err
+		}
+		script = d.Script()
+	} else {
+		var err error
+		if script, err = converter.DetectScriptReader(rs); err != nil {
+			return Unknown, err
+		}
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return Unknown, err
+	}
+	if parseDetected(script) == Cyrillic {
+		return Latin, nil
+	}
+	return Cyrillic, nil
+}
+
+// NewTransformer returns a streaming transform.Transformer for opts,
+// which must name Latin or Cyrillic as the target. Its output equals
+// Convert on the whole input. Document formats are buffered whole and
+// may leave the target Unknown.
+func NewTransformer(opts ConvertOptions) (transform.Transformer, error) {
+	to := targetOf(opts)
+	switch {
+	case opts.Format != FormatText:
+		return converter.NewWholeTransformer(func(text string) (string, error) {
+			return Convert(text, opts)
+		}), nil
+	case to != Latin && to != Cyrillic:
+		return nil, ErrUnknownScript
+	case needsLineContext(to, opts):
+		return converter.NewLineChunkTransformer(convertFunc(to, opts)), nil
+	case opts.Dictionary != nil || opts.PerSegment:
+		return converter.NewChunkTransformer(convertFunc(to, opts)), nil
+	}
+	return letterTransformer(to, opts.Lookalikes), nil
+}
+
+// DetectScript reports which script dominates text.
+func DetectScript(text string) Script {
+	return parseDetected(converter.DetectScript(text))
+}
+
+func parseDetected(script string) Script {
+	switch script {
+	case "cyrillic":
+		return Cyrillic
+	case "latin":
+		return Latin
+	default:
+		return Unknown
+	}
+}
+
+// DetectScriptReader is like DetectScript but reads the text from r in
+// constant memory.
+func DetectScriptReader(r io.Reader) (Script, error) {
+	script, err := converter.DetectScriptReader(r)
+	if err != nil {
+		return Unknown, err
+	}
+	return parseDetected(script), nil
+}
+
+// resolveTarget validates to, replacing Unknown with the script opposite
+// to the one detected in text.
+func resolveTarget(text string, to Script) (Script, error) {
+	switch to {
+	case Latin, Cyrillic:
+		return to, nil
+	case Unknown:
+		if DetectScript(text) == Cyrillic {
+			return Latin, nil
+		}
+		return Cyrillic, nil
+	}
+	return Unknown, ErrUnknownScript
+}
+
+// NewLatin2CyrillicTransformer returns a streaming transform.Transformer
+// equivalent to Latin2Cyrillic, for use with transform.NewReader,
+// transform.NewWriter or transform.Chain. The transformer is stateful; do
+// not share it between goroutines.
+func NewLatin2CyrillicTransformer() transform.Transformer {
+	return letterTransformer(Cyrillic, false)
+}
+
+// NewCyrillic2LatinTransformer returns a streaming transform.Transformer
+// equivalent to Cyrillic2Latin. The transformer is stateful; do not share
+// it between goroutines.
+func NewCyrillic2LatinTransformer() transform.Transformer {
+	return letterTransformer(Latin, false)
+}
+
+// letterTransformer converts text to the script to with the letter
+// tables, after composing it to NFC and, if lookalikes is set, replacing
+// look-alike letters.
+func letterTransformer(to Script, lookalikes bool) transform.Transformer {
+	n := &converter.Normalizer{Lookalikes: lookalikes}
+	if to == Cyrillic {
+		return transform.Chain(n, converter.Latin2CyrillicTransformer{})
+	}
+	return transform.Chain(n, &converter.Cyrillic2LatinTransformer{})
+}
@@ -0,0 +1,52 @@
+package kaalin
+
+import (
+	"github.com/dontbeidle/kaalin/internal/converter"
+)
+
+// Segment is a run of text written in one script.
+type Segment struct {
+	// Start and End are byte offsets: the segment is text[Start:End].
+	Start, End int
+
+	// Script is Unknown for text without Latin or Cyrillic letters.
+	Script Script
+
+	// Confidence is the share of the segment's letters that are written
+	// in Script, from 0 to 1.
+	Confidence float64
+}
+
+// DetectSegments splits text into runs of words in the same script. Each
+// word is classified by the majority of its letters, and together the
+// segments cover the whole text: spaces, digits and punctuation belong to
+// the segment before them.
+func DetectSegments(text string) []Segment {
+	found := converter.DetectSegments(text)
+	segs := make([]Segment, len(found))
+	for i, s := range found {
+		segs[i] = Segment{Start: s.Start, End: s.End, Script: parseDetected(s.Script), Confidence: s.Confidence}
+	}
+	return segs
+}
+
+// convertSegments applies convert only to the segments of text that are
+// written in a script other than to, leaving the rest untouched.
+func convertSegments(to Script, convert func(string) string) func(string) string {
+	return func(text string) string {
+		segs := DetectSegments(text)
+		if len(segs) == 1 && segs[0].Script != to && segs[0].Script != Unknown {
+			return convert(text)
+		}
+
+		var out []byte
+		for _, s := range segs {
+			part := text[s.Start:s.End]
+			if s.Script != to && s.Script != Unknown {
+				part = convert(part)
+			}
+			out = append(out, part...)
+		}
+		return string(out)
+	}
+}
@@ -0,0 +1,87 @@
+package kaalin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/converter"
+	"gopkg.in/yaml.v3"
+)
+
+// Dictionary holds whole-word exceptions for loanwords and proper nouns.
+// Each entry pairs a Latin and a Cyrillic spelling and is used in both
+// directions. Lookups ignore case and the case pattern of the input word
+// (москва, Москва, МОСКВА) is carried over to the replacement.
+//
+// A Dictionary is safe for concurrent use once it is no longer modified.
+type Dictionary struct {
+	d *converter.Dictionary
+}
+
+// NewDictionary returns an empty dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{d: converter.NewDictionary()}
+}
+
+// DefaultDictionary returns a copy of the built-in exception list.
+func DefaultDictionary() *Dictionary {
+	return &Dictionary{d: converter.DefaultDictionary()}
+}
+
+// ParseDictionary reads a tab-separated dictionary: one "latin<TAB>cyrillic"
+// entry per line, with blank lines and # comments ignored.
+func ParseDictionary(r io.Reader) (*Dictionary, error) {
+	d, err := converter.ParseDictionaryTSV(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Dictionary{d: d}, nil
+}
+
+// LoadDictionary reads a dictionary file. Files ending in .yaml or .yml
+// hold a mapping of Latin to Cyrillic spellings; anything else is read
+// as tab-separated values.
+func LoadDictionary(path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var entries map[string]string
+		if err := yaml.NewDecoder(f).Decode(&entries); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		d := NewDictionary()
+		for latin, cyrillic := range entries {
+			d.Add(latin, cyrillic)
+		}
+		return d, nil
+	}
+
+	d, err := ParseDictionary(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return d, nil
+}
+
+// Add registers a Latin/Cyrillic pair.
+func (d *Dictionary) Add(latin, cyrillic string) {
+	d.d.Add(latin, cyrillic)
+}
+
+// Merge adds all entries of other, which take precedence over existing ones.
+func (d *Dictionary) Merge(other *Dictionary) {
+	d.d.Merge(other.d)
+}
+
+// Len returns the number of entries.
+func (d *Dictionary) Len() int {
+	return d.d.Len()
+}
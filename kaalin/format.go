@@ -0,0 +1,275 @@
+package kaalin
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/catalog"
+	"github.com/dontbeidle/kaalin/internal/markdown"
+	"github.com/dontbeidle/kaalin/internal/markup"
+	"github.com/dontbeidle/kaalin/internal/office"
+	"github.com/dontbeidle/kaalin/internal/structured"
+	"github.com/dontbeidle/kaalin/internal/subtitle"
+	"github.com/dontbeidle/kaalin/internal/table"
+)
+
+// Format is the structure of a document. Formats other than FormatText
+// convert only the human-readable text and copy everything else
+// unchanged.
+type Format int
+
+const (
+	// FormatText is plain text: everything is converted.
+	FormatText Format = iota
+	// FormatHTML converts text nodes and the title, alt and placeholder
+	// attributes, leaving script and style alone.
+	FormatHTML
+	// FormatXML converts text nodes, CDATA sections and the title, alt
+	// and placeholder attributes.
+	FormatXML
+	// FormatMarkdown converts prose, link text and image alt text, leaving
+	// code, URLs, link targets, HTML comments and front matter alone.
+	FormatMarkdown
+	// FormatSRT converts the cue text of SubRip subtitles, keeping cue
+	// numbers, timings and inline tags.
+	FormatSRT
+	// FormatVTT converts the cue text of WebVTT subtitles, keeping the
+	// header, cue identifiers, timings and settings, NOTE, STYLE and
+	// REGION blocks and inline tags.
+	FormatVTT
+	// FormatJSON converts string values, never keys, keeping
+	// placeholders such as {name}, %s and {{.Count}}.
+	FormatJSON
+	// FormatYAML converts string values, never keys, keeping
+	// placeholders, comments and anchors.
+	FormatYAML
+	// FormatCSV converts the selected columns of comma-separated values,
+	// keeping the header row.
+	FormatCSV
+	// FormatTSV is FormatCSV with tab-separated values.
+	FormatTSV
+	// FormatPO converts the msgstr strings of gettext PO and POT files,
+	// including plural forms, and updates the Language header.
+	FormatPO
+	// FormatXLIFF converts the target elements of XLIFF 1.2 and 2.0
+	// files and updates the target language.
+	FormatXLIFF
+	// FormatDOCX converts the text of a Word document's body, headers,
+	// footers, footnotes and comments, keeping styles and formatting.
+	FormatDOCX
+	// FormatODT converts the text of an OpenDocument text document,
+	// keeping styles and formatting.
+	FormatODT
+	// FormatEPUB converts the XHTML content documents of an e-book as
+	// FormatHTML does, its table of contents and its title and creator,
+	// and updates its language.
+	FormatEPUB
+)
+
+// String returns the format name.
+func (f Format) String() string {
+	switch f {
+	case FormatHTML:
+		return "html"
+	case FormatXML:
+		return "xml"
+	case FormatMarkdown:
+		return "markdown"
+	case FormatSRT:
+		return "srt"
+	case FormatVTT:
+		return "vtt"
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	case FormatCSV:
+		return "csv"
+	case FormatTSV:
+		return "tsv"
+	case FormatPO:
+		return "po"
+	case FormatXLIFF:
+		return "xliff"
+	case FormatDOCX:
+		return "docx"
+	case FormatODT:
+		return "odt"
+	case FormatEPUB:
+		return "epub"
+	default:
+		return "text"
+	}
+}
+
+// ParseFormat parses a format name such as "text", "html", "xml",
+// "markdown", "srt", "vtt", "json", "yaml", "csv", "tsv", "po", "xliff",
+// "docx", "odt" or "epub".
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "text", "txt", "plain":
+		return FormatText, nil
+	case "html", "htm", "xhtml":
+		return FormatHTML, nil
+	case "xml":
+		return FormatXML, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "srt", "subrip":
+		return FormatSRT, nil
+	case "vtt", "webvtt":
+		return FormatVTT, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "csv":
+		return FormatCSV, nil
+	case "tsv", "tab":
+		return FormatTSV, nil
+	case "po", "pot", "gettext":
+		return FormatPO, nil
+	case "xliff", "xlf":
+		return FormatXLIFF, nil
+	case "docx":
+		return FormatDOCX, nil
+	case "odt":
+		return FormatODT, nil
+	case "epub":
+		return FormatEPUB, nil
+	}
+	return FormatText, fmt.Errorf("%q: %w", name, ErrUnknownFormat)
+}
+
+// IsBinary reports whether documents in f are binary containers rather
+// than text.
+func (f Format) IsBinary() bool {
+	return f == FormatDOCX || f == FormatODT || f == FormatEPUB
+}
+
+// FormatForFile returns the binary format named by the extension of
+// path, such as .docx, .odt or .epub. Text formats are never inferred, since a
+// .html or .md file may just as well be meant as plain text.
+func FormatForFile(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if f, err := ParseFormat(strings.TrimPrefix(ext, ".")); err == nil && f.IsBinary() {
+		return f, true
+	}
+	return FormatText, false
+}
+
+// ErrUnknownFormat is returned when a format name is not recognised.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ErrInvalidPath is returned when a ConvertOptions.Paths selector cannot
+// be parsed.
+var ErrInvalidPath = structured.ErrInvalidPath
+
+// ErrUnknownColumn is returned when a ConvertOptions.Columns entry is
+// neither a header name nor a column number.
+var ErrUnknownColumn = table.ErrUnknownColumn
+
+// CheckPaths reports whether paths are valid selectors for
+// ConvertOptions.Paths.
+func CheckPaths(paths []string) error {
+	_, err := structured.ParseSelector(paths)
+	return err
+}
+
+// convertibleAttrs are the HTML and XML attributes that hold
+// human-readable text.
+var convertibleAttrs = []string{"title", "alt", "placeholder"}
+
+// convertDocument converts a document in opts.Format. With an Unknown
+// target the direction is detected from the document's text only.
+func convertDocument(text string, opts ConvertOptions) (string, error) {
+	to := targetOf(opts)
+	if to == Unknown {
+		var sample strings.Builder
+		_, err := formatConvert(text, Unknown, opts, func(s string) string {
+			sample.WriteString(s)
+			sample.WriteByte(' ')
+			return s
+		})
+		if err != nil {
+			return "", err
+		}
+		to, _ = resolveTarget(sample.String(), Unknown)
+	}
+	if to != Latin && to != Cyrillic {
+		return "", ErrUnknownScript
+	}
+
+	docOpts := opts
+	docOpts.Format = FormatText
+	return formatConvert(text, to, opts, convertFunc(to, docOpts))
+}
+
+// formatConvert applies convert to the text of a document in
+// opts.Format. to is the target script, used to update language tags, or
+// Unknown.
+func formatConvert(text string, to Script, opts ConvertOptions, convert func(string) string) (string, error) {
+	html := markup.Options{
+		XML:   opts.Format == FormatXML,
+		Attrs: convertibleAttrs,
+		Lang:  scriptSubtag(to),
+	}
+	switch opts.Format {
+	case FormatHTML, FormatXML:
+		return markup.Convert(text, convert, html), nil
+	case FormatMarkdown:
+		return markdown.Convert(text, convert, markdown.Options{
+			FrontMatter: opts.FrontMatter,
+			HTML:        html,
+		}), nil
+	case FormatSRT:
+		return subtitle.ConvertSRT(text, convert)
+	case FormatVTT:
+		return subtitle.ConvertVTT(text, convert)
+	case FormatJSON, FormatYAML:
+		sel, err := structured.ParseSelector(opts.Paths)
+		if err != nil {
+			return "", err
+		}
+		if opts.Format == FormatJSON {
+			return structured.ConvertJSON(text, convert, sel)
+		}
+		return structured.ConvertYAML(text, convert, sel)
+	case FormatCSV, FormatTSV:
+		var b strings.Builder
+		err := table.Convert(&b, strings.NewReader(text), convert, tableOptions(opts))
+		return b.String(), err
+	case FormatPO:
+		return catalog.ConvertPO(text, convert, catalog.Options{Lang: scriptSubtag(to), Fuzzy: opts.MarkFuzzy})
+	case FormatXLIFF:
+		return catalog.ConvertXLIFF(text, convert, catalog.Options{Lang: scriptSubtag(to), Fuzzy: opts.MarkFuzzy}), nil
+	case FormatDOCX:
+		return office.ConvertDOCX(text, convert)
+	case FormatODT:
+		return office.ConvertODT(text, convert)
+	case FormatEPUB:
+		return office.ConvertEPUB(text, convert, html)
+	}
+	return convert(text), nil
+}
+
+func tableOptions(opts ConvertOptions) table.Options {
+	comma := ','
+	if opts.Format == FormatTSV {
+		comma = '\t'
+	}
+	return table.Options{Comma: comma, Columns: opts.Columns, Suffix: opts.AppendSuffix}
+}
+
+// scriptSubtag returns the BCP 47 script subtag of s, or "" for Unknown.
+func scriptSubtag(s Script) string {
+	switch s {
+	case Latin:
+		return "Latn"
+	case Cyrillic:
+		return "Cyrl"
+	}
+	return ""
+}
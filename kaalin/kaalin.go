@@ -0,0 +1,169 @@
+// Package kaalin provides text tools for the Karakalpak language:
+// Latin ↔ Cyrillic script conversion, numbers to words and
+// alphabet-aware letter casing.
+package kaalin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dontbeidle/kaalin/internal/number"
+)
+
+// Script identifies a Karakalpak writing system.
+type Script int
+
+const (
+	// Unknown means no script could be determined. As a conversion target
+	// it asks for the opposite of the detected source script.
+	Unknown Script = iota
+	// Latin is the Karakalpak Latin alphabet.
+	Latin
+	// Cyrillic is the Karakalpak Cyrillic alphabet.
+	Cyrillic
+)
+
+// String returns "latin", "cyrillic" or "unknown".
+func (s Script) String() string {
+	switch s {
+	case Latin:
+		return "latin"
+	case Cyrillic:
+		return "cyrillic"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseScript parses a script name such as "lat", "latin", "cyr" or "cyrillic".
+func ParseScript(name string) (Script, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "lat", "latin", "latn":
+		return Latin, nil
+	case "cyr", "cyrillic", "cyrl":
+		return Cyrillic, nil
+	}
+	return Unknown, fmt.Errorf("%q: %w", name, ErrUnknownScript)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Script) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the names
+// understood by ParseScript and "unknown".
+func (s *Script) UnmarshalText(text []byte) error {
+	if string(text) == "unknown" {
+		*s = Unknown
+		return nil
+	}
+	script, err := ParseScript(string(text))
+	if err != nil {
+		return err
+	}
+	*s = script
+	return nil
+}
+
+// Alphabet identifies a Karakalpak alphabet: a script and, for Latin,
+// its orthography.
+type Alphabet int
+
+const (
+	// AlphabetUnknown leaves the alphabet to the script: current Latin or Cyrillic.
+	AlphabetUnknown Alphabet = iota
+	// AlphabetLatin is the 2016 Latin alphabet with acute accents (á ó ú ǵ ń ı).
+	AlphabetLatin
+	// AlphabetLatinOld is the 1994/2009 Latin alphabet with apostrophes
+	// (a' o' u' g' n' i').
+	AlphabetLatinOld
+	// AlphabetCyrillic is the Cyrillic alphabet.
+	AlphabetCyrillic
+)
+
+// String returns "latin", "latin-old", "cyrillic" or "unknown".
+func (a Alphabet) String() string {
+	switch a {
+	case AlphabetLatin:
+		return "latin"
+	case AlphabetLatinOld:
+		return "latin-old"
+	case AlphabetCyrillic:
+		return "cyrillic"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Alphabet) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the names
+// understood by ParseAlphabet and "unknown".
+func (a *Alphabet) UnmarshalText(text []byte) error {
+	if string(text) == "unknown" {
+		*a = AlphabetUnknown
+		return nil
+	}
+	alphabet, err := ParseAlphabet(string(text))
+	if err != nil {
+		return err
+	}
+	*a = alphabet
+	return nil
+}
+
+// Script returns the script the alphabet is written in.
+func (a Alphabet) Script() Script {
+	switch a {
+	case AlphabetLatin, AlphabetLatinOld:
+		return Latin
+	case AlphabetCyrillic:
+		return Cyrillic
+	default:
+		return Unknown
+	}
+}
+
+// ParseAlphabet parses an alphabet name: "latin" (2016), "latin-old"
+// (also "latin-1994", "latin-2009", "apostrophe") or "cyrillic". Script
+// abbreviations accepted by ParseScript work as well.
+func ParseAlphabet(name string) (Alphabet, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "latin-old", "latin-1994", "latin-2009", "apostrophe", "old":
+		return AlphabetLatinOld, nil
+	case "latin-2016":
+		return AlphabetLatin, nil
+	}
+	script, err := ParseScript(name)
+	if err != nil {
+		return AlphabetUnknown, fmt.Errorf("%q: %w", name, ErrUnknownAlphabet)
+	}
+	if script == Cyrillic {
+		return AlphabetCyrillic, nil
+	}
+	return AlphabetLatin, nil
+}
+
+var (
+	// ErrUnknownAlphabet is returned when an alphabet name is not recognised.
+	ErrUnknownAlphabet = errors.New("unknown alphabet")
+
+	// ErrUnknownScript is returned when a script name or value is not
+	// Latin or Cyrillic.
+	ErrUnknownScript = errors.New("unknown script")
+
+	// ErrInvalidNumber is returned for NaN, infinite and malformed numbers.
+	ErrInvalidNumber = number.ErrInvalidNumber
+
+	// ErrOutOfRange is returned for numbers too large to be spelled out.
+	ErrOutOfRange = number.ErrOutOfRange
+
+	// ErrInvalidWords is returned for text that is not a number spelled
+	// out in words.
+	ErrInvalidWords = number.ErrInvalidWords
+)
@@ -0,0 +1,706 @@
+package kaalin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		to    Script
+		want  string
+	}{
+		{"to cyrillic", "Sálem", Cyrillic, "Сәлем"},
+		{"to latin", "Сәлем", Latin, "Sálem"},
+		{"auto from latin", "Assalawma áleykum", Unknown, "Ассалаўма әлейкум"},
+		{"auto from cyrillic", "Ассалаўма әлейкум", Unknown, "Assalawma áleykum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.input, ConvertOptions{To: tt.to})
+			if err != nil {
+				t.Fatalf("Convert(%q, %v) returned error: %v", tt.input, tt.to, err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%q, %v) = %q, want %q", tt.input, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertInvalidScript(t *testing.T) {
+	_, err := Convert("Sálem", ConvertOptions{To: Script(42)})
+	if !errors.Is(err, ErrUnknownScript) {
+		t.Errorf("Convert with invalid script: err = %v, want ErrUnknownScript", err)
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Script
+	}{
+		{"lat", Latin},
+		{"Latin", Latin},
+		{"cyr", Cyrillic},
+		{"cyrillic", Cyrillic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseScript(tt.input)
+			if err != nil {
+				t.Fatalf("ParseScript(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseScript(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseScript("greek"); !errors.Is(err, ErrUnknownScript) {
+		t.Errorf("ParseScript(greek): err = %v, want ErrUnknownScript", err)
+	}
+}
+
+func TestDetectScript(t *testing.T) {
+	if got := DetectScript("Сәлем"); got != Cyrillic {
+		t.Errorf("DetectScript(Сәлем) = %v, want cyrillic", got)
+	}
+	if got := DetectScript("Sálem"); got != Latin {
+		t.Errorf("DetectScript(Sálem) = %v, want latin", got)
+	}
+	if got := DetectScript("123"); got != Unknown {
+		t.Errorf("DetectScript(123) = %v, want unknown", got)
+	}
+}
+
+func TestNumberToWords(t *testing.T) {
+	got, err := NumberToWords(123, NumberOptions{})
+	if err != nil {
+		t.Fatalf("NumberToWords(123) returned error: %v", err)
+	}
+	if want := "bir júz jigirma úsh"; got != want {
+		t.Errorf("NumberToWords(123) = %q, want %q", got, want)
+	}
+
+	got, err = NumberToWords(123, NumberOptions{Script: Cyrillic})
+	if err != nil {
+		t.Fatalf("NumberToWords(123, cyr) returned error: %v", err)
+	}
+	if want := "бир жүз жигирма үш"; got != want {
+		t.Errorf("NumberToWords(123, cyr) = %q, want %q", got, want)
+	}
+}
+
+func TestNumberToWordsErrors(t *testing.T) {
+	if _, err := NumberToWords(1e34, NumberOptions{}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("NumberToWords(1e34): err = %v, want ErrOutOfRange", err)
+	}
+	if _, err := DecimalToWords("12,5", NumberOptions{}); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("DecimalToWords(12,5): err = %v, want ErrInvalidNumber", err)
+	}
+	if _, err := NumberToWords(math.NaN(), NumberOptions{}); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("NumberToWords(NaN): err = %v, want ErrInvalidNumber", err)
+	}
+}
+
+func TestDecimalToWords(t *testing.T) {
+	got, err := DecimalToWords("12345678901234567890", NumberOptions{})
+	if err != nil {
+		t.Fatalf("DecimalToWords returned error: %v", err)
+	}
+	if back, _ := WordsToNumber(got); back != "12345678901234567890" {
+		t.Errorf("DecimalToWords = %q, which reads back as %q", got, back)
+	}
+}
+
+func TestFractionToWords(t *testing.T) {
+	got, err := FractionToWords("2 1/2", NumberOptions{Script: Cyrillic})
+	if err != nil {
+		t.Fatalf("FractionToWords returned error: %v", err)
+	}
+	if want := "еки пүтін екиден бир"; got != want {
+		t.Errorf("FractionToWords(2 1/2, cyr) = %q, want %q", got, want)
+	}
+
+	got, err = PercentToWords("15%", NumberOptions{})
+	if err != nil {
+		t.Fatalf("PercentToWords returned error: %v", err)
+	}
+	if want := "on bes procent"; got != want {
+		t.Errorf("PercentToWords(15%%) = %q, want %q", got, want)
+	}
+}
+
+func TestOrdinalToWords(t *testing.T) {
+	got, err := OrdinalToWords("1000", NumberOptions{Script: Cyrillic})
+	if err != nil {
+		t.Fatalf("OrdinalToWords returned error: %v", err)
+	}
+	if want := "мыңыншы"; got != want {
+		t.Errorf("OrdinalToWords(1000, cyr) = %q, want %q", got, want)
+	}
+	if _, err := OrdinalToWords("2.5", NumberOptions{}); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("OrdinalToWords(2.5): err = %v, want ErrInvalidNumber", err)
+	}
+}
+
+func TestWordsToNumber(t *testing.T) {
+	got, err := WordsToNumber("он еки пүтін жүзден жетпис бес")
+	if err != nil {
+		t.Fatalf("WordsToNumber returned error: %v", err)
+	}
+	if want := "12.75"; got != want {
+		t.Errorf("WordsToNumber = %q, want %q", got, want)
+	}
+	if _, err := WordsToNumber("bir eki"); !errors.Is(err, ErrInvalidWords) {
+		t.Errorf("WordsToNumber(bir eki): err = %v, want ErrInvalidWords", err)
+	}
+}
+
+func TestCase(t *testing.T) {
+	if got := Upper("qırıq"); got != "QÍRÍQ" {
+		t.Errorf("Upper(qırıq) = %q, want QÍRÍQ", got)
+	}
+	if got := Lower("QÍRÍQ"); got != "qırıq" {
+		t.Errorf("Lower(QÍRÍQ) = %q, want qırıq", got)
+	}
+}
+
+func TestTransformers(t *testing.T) {
+	got, _, err := transform.String(NewLatin2CyrillicTransformer(), "Sálem, dun'ya!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Сәлем, дун'я!"; got != want {
+		t.Errorf("Latin2Cyrillic transformer = %q, want %q", got, want)
+	}
+
+	got, _, err = transform.String(NewCyrillic2LatinTransformer(), "объект")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "obyekt"; got != want {
+		t.Errorf("Cyrillic2Latin transformer = %q, want %q", got, want)
+	}
+}
+
+func TestConvertNormalizes(t *testing.T) {
+	const input = "Sa\u0301lem, Dağ ha\u0301m tañ\nһаўа"
+	const want = "Сәлем, Дағ ҳәм таң\nҳаўа"
+	opts := ConvertOptions{To: Cyrillic, Lookalikes: true}
+
+	got, err := Convert(input, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := ConvertStream(&buf, iotest.OneByteReader(strings.NewReader(input)), opts); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("ConvertStream = %q, want %q", buf.String(), want)
+	}
+
+	_, subs := Normalize(input)
+	if len(subs) != 5 || subs[1] != (Substitution{Line: 1, Column: 11, From: "ğ", To: "ǵ", Lookalike: true}) {
+		t.Errorf("Normalize substitutions = %+v", subs)
+	}
+}
+
+func TestConvertKeepsLookalikes(t *testing.T) {
+	// Without Lookalikes, letters of other languages pass through as they
+	// are and only combining accents are composed.
+	tests := []struct {
+		input string
+		to    Script
+		want  string
+	}{
+		{"Sa\u0301lem, Dağ tañ İnim", Cyrillic, "Сәлем, Даğ таñ İним"},
+		{"башҡорт һүҙ", Latin, "bashҡort һúҙ"},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.input, ConvertOptions{To: tt.to})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+
+		var buf bytes.Buffer
+		if err := ConvertStream(&buf, strings.NewReader(tt.input), ConvertOptions{To: tt.to}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("ConvertStream(%q) = %q, want %q", tt.input, buf.String(), tt.want)
+		}
+	}
+	if got, want := Latin2Cyrillic("Dağ"), "Даğ"; got != want {
+		t.Errorf("Latin2Cyrillic(Dağ) = %q, want %q", got, want)
+	}
+}
+
+func TestConvertStream(t *testing.T) {
+	input := strings.Repeat("Sálem, dun'ya! SHAHAR chaqqan yaman\n", 4000)
+
+	for _, to := range []Script{Cyrillic, Latin, Unknown} {
+		want, err := Convert(input, ConvertOptions{To: to})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		src := iotest.HalfReader(strings.NewReader(input))
+		if err := ConvertStream(&buf, src, ConvertOptions{To: to}); err != nil {
+			t.Fatalf("ConvertStream(%v) returned error: %v", to, err)
+		}
+		if buf.String() != want {
+			t.Errorf("ConvertStream(%v) output differs from Convert", to)
+		}
+	}
+}
+
+func TestConvertStreamDetectsWholeInput(t *testing.T) {
+	// The first 64 KiB are Latin, but most of the text is Cyrillic.
+	input := strings.Repeat("Sálem dunya\n", 8000) + strings.Repeat("Сәлем дүнья\n", 20000)
+	csv := "name\n" + input
+
+	for _, opts := range []ConvertOptions{{}, {Format: FormatCSV}} {
+		text := input
+		if opts.Format == FormatCSV {
+			text = csv
+		}
+		want, err := Convert(text, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(want, "Сәлем") {
+			t.Fatalf("Convert(%v) did not convert to Latin", opts.Format)
+		}
+
+		for _, src := range []io.Reader{strings.NewReader(text), iotest.HalfReader(strings.NewReader(text))} {
+			var buf bytes.Buffer
+			if err := ConvertStream(&buf, src, opts); err != nil {
+				t.Fatalf("ConvertStream(%v) returned error: %v", opts.Format, err)
+			}
+			if buf.String() != want {
+				t.Errorf("ConvertStream(%v) output differs from Convert", opts.Format)
+			}
+		}
+	}
+}
+
+func TestConvertDictionary(t *testing.T) {
+	opts := ConvertOptions{To: Cyrillic, Dictionary: DefaultDictionary()}
+
+	got, err := Convert("MOSKVA, 1-iyun", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "МОСКВА, 1-июнь"; got != want {
+		t.Errorf("Convert with dictionary = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	input := strings.Repeat("Moskva kompyuter sentyabr\n", 1000)
+	if err := ConvertStream(&buf, iotest.HalfReader(strings.NewReader(input)), opts); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Convert(input, opts)
+	if buf.String() != want {
+		t.Error("ConvertStream with dictionary differs from Convert")
+	}
+}
+
+func TestLoadDictionary(t *testing.T) {
+	dir := t.TempDir()
+
+	tsv := filepath.Join(dir, "dict.tsv")
+	if err := os.WriteFile(tsv, []byte("# names\nSirk\tцирк\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yml := filepath.Join(dir, "dict.yaml")
+	if err := os.WriteFile(yml, []byte("Sirk: цирк\nkonsert: концерт\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, n := range map[string]int{tsv: 1, yml: 2} {
+		d, err := LoadDictionary(path)
+		if err != nil {
+			t.Fatalf("LoadDictionary(%s) returned error: %v", path, err)
+		}
+		if d.Len() != n {
+			t.Errorf("LoadDictionary(%s).Len() = %d, want %d", path, d.Len(), n)
+		}
+		got, _ := Convert("Цирк", ConvertOptions{To: Latin, Dictionary: d})
+		if got != "Sirk" {
+			t.Errorf("Convert(Цирк) with %s = %q, want Sirk", path, got)
+		}
+	}
+}
+
+func TestConvertAlphabets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  ConvertOptions
+		want  string
+	}{
+		{"old latin to cyrillic", "Sa'lem, dun'ya!", ConvertOptions{From: AlphabetLatinOld, To: Cyrillic}, "Сәлем, дун'я!"},
+		{"old latin to latin", "qi'ri'q g'arri", ConvertOptions{From: AlphabetLatinOld, ToAlphabet: AlphabetLatin}, "qırıq ǵarri"},
+		{"latin to old latin", "Sálem, meniń", ConvertOptions{ToAlphabet: AlphabetLatinOld}, "Sa'lem, menin'"},
+		{"cyrillic to old latin", "Сәлем", ConvertOptions{ToAlphabet: AlphabetLatinOld}, "Sa'lem"},
+		{"old latin auto", "Sa'lem", ConvertOptions{From: AlphabetLatinOld}, "Сәлем"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.input, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			src := iotest.OneByteReader(strings.NewReader(tt.input))
+			if err := ConvertStream(&buf, src, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("ConvertStream(%q) = %q, want %q", tt.input, buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAlphabet(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Alphabet
+	}{
+		{"latin", AlphabetLatin},
+		{"lat", AlphabetLatin},
+		{"latin-old", AlphabetLatinOld},
+		{"latin-1994", AlphabetLatinOld},
+		{"cyrillic", AlphabetCyrillic},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAlphabet(tt.input)
+		if err != nil {
+			t.Fatalf("ParseAlphabet(%q) returned error: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseAlphabet(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseAlphabet("runic"); !errors.Is(err, ErrUnknownAlphabet) {
+		t.Errorf("ParseAlphabet(runic): err = %v, want ErrUnknownAlphabet", err)
+	}
+}
+
+func TestConvertLossless(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  ConvertOptions
+	}{
+		{"cyrillic to latin", "Объект ҳәм щётка, поэзия\nасҳаб", ConvertOptions{To: Latin}},
+		{"latin to cyrillic", "Sálem, hello world", ConvertOptions{To: Cyrillic}},
+		{"detected", "съезд", ConvertOptions{}},
+		{"dictionary", "Москва объект", ConvertOptions{To: Latin, Dictionary: DefaultDictionary()}},
+		{"old latin", "Sa'lem 'bala' объект", ConvertOptions{From: AlphabetLatinOld, To: Cyrillic}},
+		{"decomposed", "Sa\u0301lem, Dag\u0306", ConvertOptions{To: Cyrillic}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rt, err := ConvertLossless(tt.input, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, _ := Convert(tt.input, tt.opts); got != want {
+				t.Errorf("ConvertLossless(%q) = %q, want %q", tt.input, got, want)
+			}
+
+			data, err := json.Marshal(rt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded RoundTrip
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", data, err)
+			}
+
+			restored, err := Restore(got, &decoded, tt.opts.Dictionary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored != tt.input {
+				t.Errorf("Restore(%q) = %q, want %q", got, restored, tt.input)
+			}
+		})
+	}
+}
+
+func TestConvertLosslessSpans(t *testing.T) {
+	got, rt, err := ConvertLossless("бул поэзия", ConvertOptions{To: Latin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Span{{Offset: 4, Length: 7, Original: "поэзия"}}
+	if got != "bul poeziya" || !reflect.DeepEqual(rt.Spans, want) {
+		t.Errorf("ConvertLossless = %q, %+v; want %q, %+v", got, rt.Spans, "bul poeziya", want)
+	}
+	if rt.From != AlphabetCyrillic || rt.To != AlphabetLatin {
+		t.Errorf("alphabets = %v → %v, want cyrillic → latin", rt.From, rt.To)
+	}
+}
+
+func TestDetectSegments(t *testing.T) {
+	text := "Сәлем, hello"
+	segs := DetectSegments(text)
+	want := []Segment{
+		{Start: 0, End: 12, Script: Cyrillic, Confidence: 1},
+		{Start: 12, End: 17, Script: Latin, Confidence: 1},
+	}
+	if !reflect.DeepEqual(segs, want) {
+		t.Errorf("DetectSegments(%q) = %+v, want %+v", text, segs, want)
+	}
+}
+
+func TestConvertPerSegment(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  ConvertOptions
+		want  string
+	}{
+		{"to latin", "Сәлем, hello Шаҳар", ConvertOptions{To: Latin, PerSegment: true}, "Sálem, hello Shahar"},
+		{"to cyrillic", "Sálem, Ҳелло shahar", ConvertOptions{To: Cyrillic, PerSegment: true}, "Сәлем, Ҳелло шаҳар"},
+		{"keeps target script", "hello Сәлем", ConvertOptions{To: Cyrillic, PerSegment: true}, "ҳелло Сәлем"},
+		{"old latin", "Sa'lem сөз", ConvertOptions{From: AlphabetLatinOld, To: Cyrillic, PerSegment: true}, "Сәлем сөз"},
+		{"to old latin", "Sálem сөз", ConvertOptions{ToAlphabet: AlphabetLatinOld, PerSegment: true}, "Sa'lem so'z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.input, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			src := iotest.OneByteReader(strings.NewReader(tt.input))
+			if err := ConvertStream(&buf, src, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("ConvertStream(%q) = %q, want %q", tt.input, buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTML(t *testing.T) {
+	input := `<html lang="kaa-Latn"><title>Sálem</title><script>var x = "shahar";</script><p title="Qala" class="shahar">Shahar &amp; awıl</p></html>`
+	want := `<html lang="kaa-Cyrl"><title>Сәлем</title><script>var x = "shahar";</script><p title="Қала" class="shahar">Шаҳар &amp; аўыл</p></html>`
+
+	for _, opts := range []ConvertOptions{{To: Cyrillic, Format: FormatHTML}, {Format: FormatHTML}} {
+		got, err := Convert(input, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Convert(%q, %+v) = %q, want %q", input, opts, got, want)
+		}
+
+		var buf bytes.Buffer
+		if err := ConvertStream(&buf, iotest.OneByteReader(strings.NewReader(input)), opts); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("ConvertStream(%q, %+v) = %q, want %q", input, opts, buf.String(), want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": FormatText, "text": FormatText, "HTML": FormatHTML, "xml": FormatXML} {
+		got, err := ParseFormat(name)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("pdf"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ParseFormat(pdf): err = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestConvertMarkdown(t *testing.T) {
+	input := "---\ntitle: Sálem\nlayout: post\n---\n# Shahar\n\nRun `kaalin convert` on [the docs](https://example.com/shahar).\n"
+	want := "---\ntitle: Сәлем\nlayout: post\n---\n# Шаҳар\n\nРун `kaalin convert` он [тҳе доцс](https://example.com/shahar).\n"
+
+	got, err := Convert(input, ConvertOptions{To: Cyrillic, Format: FormatMarkdown, FrontMatter: []string{"title"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+}
+
+func TestConvertSubtitles(t *testing.T) {
+	input := "WEBVTT\n\nNOTE shahar\n\n1\n00:01.000 --> 00:02.000 align:start\n<i>Sálem</i>\n"
+	want := "WEBVTT\n\nNOTE shahar\n\n1\n00:01.000 --> 00:02.000 align:start\n<i>Сәлем</i>\n"
+
+	got, err := Convert(input, ConvertOptions{Format: FormatVTT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+
+	if _, err := Convert("1\nbroken\n", ConvertOptions{To: Cyrillic, Format: FormatSRT}); err == nil {
+		t.Error("Convert of a malformed SRT: no error")
+	}
+}
+
+func TestConvertStructured(t *testing.T) {
+	input := `{"messages": {"hello": "Sálem, {name}!", "count": "%d kitap"}, "shahar": "shahar"}`
+	want := `{"messages": {"hello": "Сәлем, {name}!", "count": "%d китап"}, "shahar": "shahar"}`
+
+	got, err := Convert(input, ConvertOptions{To: Cyrillic, Format: FormatJSON, Paths: []string{"$.messages.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+
+	yamlInput := "# greetings\nhello: Сәлем, {{.Name}}\n"
+	yamlWant := "# greetings\nhello: Sálem, {{.Name}}\n"
+	if got, err := Convert(yamlInput, ConvertOptions{Format: FormatYAML}); err != nil || got != yamlWant {
+		t.Errorf("Convert(%q) = %q, %v; want %q", yamlInput, got, err, yamlWant)
+	}
+
+	if _, err := Convert("{}", ConvertOptions{To: Cyrillic, Format: FormatJSON, Paths: []string{"messages"}}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Convert with a bad path: err = %v, want ErrInvalidPath", err)
+	}
+}
+
+func TestConvertTable(t *testing.T) {
+	input := "id,name,city\n1,Shahar,Nókis\n"
+	want := "id,name,city,name_cyr\n1,Shahar,Nókis,Шаҳар\n"
+	opts := ConvertOptions{To: Cyrillic, Format: FormatCSV, Columns: []string{"name"}, AppendSuffix: "_cyr"}
+
+	got, err := Convert(input, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := ConvertStream(&buf, strings.NewReader(input), opts); err != nil || buf.String() != want {
+		t.Errorf("ConvertStream(%q) = %q, %v; want %q", input, buf.String(), err, want)
+	}
+
+	tsv := "a\tb\nСәлем\tx\n"
+	buf.Reset()
+	if err := ConvertStream(&buf, strings.NewReader(tsv), ConvertOptions{Format: FormatTSV}); err != nil || buf.String() != "a\tb\nSálem\tx\n" {
+		t.Errorf("ConvertStream(%q) = %q, %v", tsv, buf.String(), err)
+	}
+
+	if _, err := Convert(input, ConvertOptions{To: Cyrillic, Format: FormatCSV, Columns: []string{"phone"}}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("Convert with an unknown column: err = %v, want ErrUnknownColumn", err)
+	}
+}
+
+func TestConvertCatalog(t *testing.T) {
+	input := "msgid \"\"\nmsgstr \"Language: kaa_Latn\\n\"\n\n#, c-format\nmsgid \"%d cities\"\nmsgstr \"%d shahar\"\n"
+	want := "msgid \"\"\nmsgstr \"Language: kaa_Cyrl\\n\"\n\n#, c-format, fuzzy\nmsgid \"%d cities\"\nmsgstr \"%d шаҳар\"\n"
+
+	got, err := Convert(input, ConvertOptions{Format: FormatPO, MarkFuzzy: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Convert(%q) = %q, want %q", input, got, want)
+	}
+}
+
+func TestConvertOffice(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>S</w:t></w:r><w:r><w:t>hahar</w:t></w:r></w:p>`))
+	zw.Close()
+
+	out, err := Convert(buf.String(), ConvertOptions{Format: FormatDOCX})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, _ := zr.File[0].Open()
+	doc, _ := io.ReadAll(rc)
+	if want := `<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Ш</w:t></w:r><w:r><w:t>аҳар</w:t></w:r></w:p>`; string(doc) != want {
+		t.Errorf("document.xml = %s, want %s", doc, want)
+	}
+
+	if f, ok := FormatForFile("Letter.DOCX"); !ok || f != FormatDOCX {
+		t.Errorf("FormatForFile(Letter.DOCX) = %v, %v", f, ok)
+	}
+	if f, ok := FormatForFile("dir/book.epub"); !ok || f != FormatEPUB {
+		t.Errorf("FormatForFile(book.epub) = %v, %v", f, ok)
+	}
+	if _, ok := FormatForFile("page.html"); ok {
+		t.Error("FormatForFile(page.html) inferred a format")
+	}
+}
+
+func TestFindMixedScript(t *testing.T) {
+	words := FindMixedScript("бул Сaлем")
+	if len(words) != 1 {
+		t.Fatalf("FindMixedScript = %+v, want one word", words)
+	}
+	w := words[0]
+	if w.Line != 1 || w.Column != 5 || w.Script != Cyrillic || w.Suggestion != "Салем" || !w.Fixable {
+		t.Errorf("FindMixedScript = %+v", w)
+	}
+	if got := FixMixedScript("бул Сaлем"); got != "бул Салем" {
+		t.Errorf("FixMixedScript = %q", got)
+	}
+}
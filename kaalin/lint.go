@@ -0,0 +1,57 @@
+package kaalin
+
+import (
+	"github.com/dontbeidle/kaalin/internal/converter"
+)
+
+// MixedWord is a word that mixes Latin and Cyrillic letters, such as a
+// Cyrillic word typed with a Latin a, o, e, c or x. Such words look right
+// but break search and conversion.
+type MixedWord struct {
+	// Start and End are byte offsets: the word is text[Start:End].
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Line and Column are 1-based; Column counts runes.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+
+	Word string `json:"word"`
+
+	// Script is the script the word is meant to be written in.
+	Script Script `json:"script"`
+
+	// Suggestion is the word with the look-alike letters of the other
+	// script replaced. Fixable reports whether that leaves the word wholly
+	// in Script; letters without a look-alike, like ǵ or ғ, cannot be
+	// replaced.
+	Suggestion string `json:"suggestion"`
+	Fixable    bool   `json:"fixable"`
+}
+
+// FindMixedScript returns the words of text that contain both Latin and
+// Cyrillic letters, with the spelling each is likely meant to have.
+func FindMixedScript(text string) []MixedWord {
+	found := converter.FindMixedScript(text)
+	words := make([]MixedWord, len(found))
+	for i, w := range found {
+		words[i] = MixedWord{
+			Start:      w.Start,
+			End:        w.End,
+			Line:       w.Line,
+			Column:     w.Column,
+			Word:       w.Word,
+			Script:     parseDetected(w.Script),
+			Suggestion: w.Suggestion,
+			Fixable:    w.Fixable,
+		}
+	}
+	return words
+}
+
+// FixMixedScript replaces the look-alike letters of the mixed-script
+// words of text that can be written wholly in one script, and leaves the
+// others as they are.
+func FixMixedScript(text string) string {
+	return converter.FixMixedScript(text)
+}
@@ -0,0 +1,104 @@
+package kaalin
+
+import (
+	"github.com/dontbeidle/kaalin/internal/converter"
+)
+
+// Span marks a word of converted text that the reverse conversion would
+// not restore, with the original spelling. Offset and Length are in bytes
+// of the converted text.
+type Span struct {
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Original string `json:"original"`
+}
+
+// RoundTrip is the sidecar written by ConvertLossless: the alphabets of
+// the conversion and the words that need their original spelling back.
+// It marshals to JSON.
+type RoundTrip struct {
+	From  Alphabet `json:"from"`
+	To    Alphabet `json:"to"`
+	Spans []Span   `json:"spans"`
+}
+
+// ConvertLossless converts text like Convert and also returns what Restore
+// needs to get the exact original back. Cyrillic ъ, ь, щ and э, and
+// sequences such as сҳ that read back as ш, have no Latin spelling of
+// their own, so the words containing them are recorded with their
+// original form; all other words are converted back. The text is
+// treated as plain text whatever opts.Format says.
+func ConvertLossless(text string, opts ConvertOptions) (string, *RoundTrip, error) {
+	to, err := resolveTarget(text, targetOf(opts))
+	if err != nil {
+		return "", nil, err
+	}
+	opts.To = to
+	opts.Format = FormatText
+
+	rt := &RoundTrip{From: opts.From, To: opts.ToAlphabet, Spans: []Span{}}
+	if rt.From == AlphabetUnknown {
+		rt.From = defaultAlphabet(opposite(to))
+	}
+	if rt.To == AlphabetUnknown {
+		rt.To = defaultAlphabet(to)
+	}
+
+	reverse := reverseOptions(rt, opts.Dictionary)
+	result, spans := converter.ConvertLossless(text, losslessCutset(opts, reverse),
+		convertFunc(to, opts), convertFunc(reverse.To, reverse))
+	for _, s := range spans {
+		rt.Spans = append(rt.Spans, Span{Offset: s.Offset, Length: s.Length, Original: s.Original})
+	}
+	return result, rt, nil
+}
+
+// Restore returns the original of text converted by ConvertLossless. dict
+// must be the dictionary used for the conversion, or nil if there was none.
+func Restore(text string, rt *RoundTrip, dict *Dictionary) (string, error) {
+	if rt.From == AlphabetUnknown || rt.To == AlphabetUnknown {
+		return "", ErrUnknownAlphabet
+	}
+	spans := make([]converter.Span, len(rt.Spans))
+	for i, s := range rt.Spans {
+		spans[i] = converter.Span{Offset: s.Offset, Length: s.Length, Original: s.Original}
+	}
+	reverse := reverseOptions(rt, dict)
+	return converter.Restore(text, spans, convertFunc(reverse.To, reverse))
+}
+
+// reverseOptions returns the options converting back from rt.To to rt.From.
+func reverseOptions(rt *RoundTrip, dict *Dictionary) ConvertOptions {
+	return ConvertOptions{
+		To:         rt.From.Script(),
+		Dictionary: dict,
+		From:       rt.To,
+		ToAlphabet: rt.From,
+	}
+}
+
+// losslessCutset returns the bytes that separate independently converted
+// words: whitespace, or only newlines when an apostrophe alphabet is
+// involved.
+func losslessCutset(opts, reverse ConvertOptions) string {
+	if needsLineContext(opts.To, opts) || needsLineContext(reverse.To, reverse) {
+		return "\n"
+	}
+	return " \t\r\n"
+}
+
+// opposite returns the other script.
+func opposite(s Script) Script {
+	if s == Cyrillic {
+		return Latin
+	}
+	return Cyrillic
+}
+
+// defaultAlphabet returns the current alphabet of a script.
+func defaultAlphabet(s Script) Alphabet {
+	if s == Cyrillic {
+		return AlphabetCyrillic
+	}
+	return AlphabetLatin
+}
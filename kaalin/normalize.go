@@ -0,0 +1,51 @@
+package kaalin
+
+import (
+	"github.com/dontbeidle/kaalin/internal/converter"
+)
+
+// Substitution is a character replaced by Normalize. Line and Column are
+// 1-based and count runes of the input.
+type Substitution struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// Lookalike is true when a look-alike letter such as ğ was replaced,
+	// and false when a letter and combining accents were only composed.
+	Lookalike bool `json:"lookalike"`
+}
+
+// Normalize composes text to NFC and replaces look-alike letters with the
+// Karakalpak letters they stand for: ğ and ǧ with ǵ, ñ with ń, ɩ with ı,
+// İ with I, and Cyrillic һ, ҡ and ӈ with ҳ, қ and ң. Text typed on macOS
+// or copied from a PDF often spells á as a and U+0301, which the letter
+// tables would not match. Convert and the transformers compose the text
+// they convert to NFC, and replace look-alikes only with
+// ConvertOptions.Lookalikes; Normalize reports what Convert replaces with
+// it set.
+func Normalize(text string) (string, []Substitution) {
+	out, subs := converter.Normalize(text, true)
+	return out, substitutions(subs)
+}
+
+// Normalizer is a streaming transform.Transformer that records the
+// substitutions it makes. It only composes text to NFC unless its
+// Lookalikes field is set, when it is equivalent to Normalize. The zero
+// value is ready to use; do not share it between goroutines.
+type Normalizer struct {
+	converter.Normalizer
+}
+
+// Substitutions returns the replacements made so far.
+func (n *Normalizer) Substitutions() []Substitution {
+	return substitutions(n.Normalizer.Substitutions())
+}
+
+func substitutions(subs []converter.Substitution) []Substitution {
+	out := make([]Substitution, len(subs))
+	for i, s := range subs {
+		out[i] = Substitution{Line: s.Line, Column: s.Column, From: s.From, To: s.To, Lookalike: s.Lookalike}
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package kaalin
+
+import "github.com/dontbeidle/kaalin/internal/number"
+
+// NumberOptions controls number spelling.
+type NumberOptions struct {
+	// Script selects the output script. Unknown defaults to Latin.
+	Script Script
+}
+
+// NumberToWords spells n out in Karakalpak words, e.g. 123 → "bir júz jigirma úsh".
+// It returns ErrInvalidNumber for NaN or infinities and ErrOutOfRange for
+// magnitudes of 10^33 and above. Integers past 2^53 lose digits in a
+// float64; use DecimalToWords for those.
+func NumberToWords(n float64, opts NumberOptions) (string, error) {
+	script, err := numberScript(opts.Script)
+	if err != nil {
+		return "", err
+	}
+	return number.ToWord(n, script)
+}
+
+// DecimalToWords spells out a number written in decimal, such as
+// "12345678901234567890" or "-12.75", digit for digit. It returns
+// ErrInvalidNumber for text that is not a decimal number and
+// ErrOutOfRange for magnitudes of 10^33 and above.
+func DecimalToWords(decimal string, opts NumberOptions) (string, error) {
+	script, err := numberScript(opts.Script)
+	if err != nil {
+		return "", err
+	}
+	return number.DecimalToWord(decimal, script)
+}
+
+// FractionToWords spells out a common fraction or a mixed number, e.g.
+// "3/4" → "tórtten úsh" and "2 1/2" → "eki pútin ekiden bir". It returns
+// ErrInvalidNumber for malformed fractions and zero denominators.
+func FractionToWords(fraction string, opts NumberOptions) (string, error) {
+	script, err := numberScript(opts.Script)
+	if err != nil {
+		return "", err
+	}
+	return number.FractionToWord(fraction, script)
+}
+
+// PercentToWords spells out a percentage, e.g. "15%" → "on bes procent".
+func PercentToWords(percent string, opts NumberOptions) (string, error) {
+	script, err := numberScript(opts.Script)
+	if err != nil {
+		return "", err
+	}
+	return number.PercentToWord(percent, script)
+}
+
+// OrdinalToWords spells out the ordinal of a whole number written in
+// decimal, e.g. "1" → "birinshi" and "6" → "altınshı". It returns
+// ErrInvalidNumber for negative numbers and fractions.
+func OrdinalToWords(decimal string, opts NumberOptions) (string, error) {
+	script, err := numberScript(opts.Script)
+	if err != nil {
+		return "", err
+	}
+	return number.ToOrdinal(decimal, script)
+}
+
+// AbbreviateOrdinal writes the ordinal of a whole number in its short
+// form, the digits and a hyphen: "25" → "25-".
+func AbbreviateOrdinal(decimal string) (string, error) {
+	return number.AbbreviateOrdinal(decimal)
+}
+
+// WordsToNumber reads a number spelled out in Karakalpak words, in either
+// script, and returns it as a decimal string, e.g. "bir júz jigirma úsh" →
+// "123" and "он еки пүтін жүзден жетпис бес" → "12.75". Malformed words
+// give an error wrapping ErrInvalidWords that names the word at fault.
+func WordsToNumber(words string) (string, error) {
+	return number.FromWords(words)
+}
+
+func numberScript(s Script) (string, error) {
+	switch s {
+	case Unknown, Latin:
+		return "lat", nil
+	case Cyrillic:
+		return "cyr", nil
+	}
+	return "", ErrUnknownScript
+}